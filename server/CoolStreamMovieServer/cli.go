@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/controllers"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/database"
+)
+
+// runCommand runs the subcommand named by args and returns its exit code.
+// It returns -1 when args do not name a subcommand and the server should
+// start instead.
+func runCommand(args []string) int {
+	if len(args) == 0 {
+		return -1
+	}
+	switch args[0] {
+	case "import":
+		return runImport(args[1:])
+	}
+	return -1
+}
+
+// runImport implements "import [-format csv|ndjson] [-dry-run] <file|->".
+func runImport(args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := flags.String("format", "", "input format, csv or ndjson (default: from the file extension)")
+	dryRun := flags.Bool("dry-run", false, "validate and report without writing")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: CoolStreamMovieServer import [-format csv|ndjson] [-dry-run] <file|->")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	path := flags.Arg(0)
+	var input io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			log.Error().Err(err).Str("path", path).Msg("failed to open import file")
+			return 1
+		}
+		defer file.Close()
+		input = file
+		if *format == "" {
+			*format = controllers.ImportFormatFromName(path)
+		}
+	}
+	if *format == "" {
+		log.Error().Msg("cannot infer the import format, pass -format csv or -format ndjson")
+		return 2
+	}
+
+	ctx := context.Background()
+	if err := database.EnsureIndexes(ctx); err != nil {
+		log.Error().Err(err).Msg("failed to create database indexes")
+		return 1
+	}
+	report, err := controllers.ImportMovies(ctx, input, *format, *dryRun)
+	if report != nil {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(report)
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("import aborted")
+		return 1
+	}
+	if report.Failed > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,241 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/mailer"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/utils"
+)
+
+const (
+	passwordResetTTL = 30 * time.Minute
+	verifyEmailTTL   = 48 * time.Hour
+	mailTimeout      = 30 * time.Second
+)
+
+// ForgotPassword emails a password reset link. It answers the same whether
+// or not the address belongs to an account, so that it cannot be used to
+// find out who is registered. Requests are throttled per address and per IP.
+func ForgotPassword(mail mailer.Mailer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Email string `json:"email" validate:"required,email"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, errBadRequest("Invalid input data"))
+			return
+		}
+		req.Email = strings.TrimSpace(req.Email)
+		if err := validate.Struct(req); err != nil {
+			respondError(c, errValidation(err))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		if respondIfResetThrottled(ctx, c, req.Email) {
+			return
+		}
+
+		var user models.User
+		err := userCollection.FindOne(ctx, bson.M{"email": req.Email}).Decode(&user)
+		if err == nil && !user.Disabled {
+			go sendPasswordResetEmail(mail, user)
+		}
+		c.JSON(http.StatusAccepted, gin.H{"message": "If the address belongs to an account, a reset link is on its way"})
+	}
+}
+
+// ResetPassword sets a new password with the token of a reset link and signs
+// the user out everywhere. Following the link also proves the user owns the
+// email address.
+func ResetPassword() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Token       string `json:"token"        validate:"required"`
+			NewPassword string `json:"new_password" validate:"required,min=6"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, errBadRequest("Invalid input data"))
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			respondError(c, errValidation(err))
+			return
+		}
+		hashedPassword, err := HashPassword(req.NewPassword)
+		if err != nil {
+			respondError(c, errInternal("Failed to hash password", err))
+			return
+		}
+		claims, err := utils.RedeemActionToken(req.Token, models.PurposePasswordReset)
+		if err != nil {
+			respondActionTokenError(c, err)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		update := bson.M{"$set": bson.M{
+			"password":       hashedPassword,
+			"email_verified": true,
+			"updated_at":     time.Now(),
+		}}
+		result, err := userCollection.UpdateOne(ctx, bson.M{"user_id": claims.UID}, update)
+		if err != nil {
+			respondError(c, errInternal("Failed to reset password", err))
+			return
+		}
+		if result.MatchedCount == 0 {
+			respondError(c, errNotFound("User not found"))
+			return
+		}
+		if err := utils.RevokeAllTokens(claims.UID); err != nil {
+			respondError(c, errInternal("Failed to revoke tokens", err))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Password reset, please log in again"})
+	}
+}
+
+// VerifyEmail marks the email address of the user a verification link was
+// sent to as verified. Tokens issued before carry the old state until they
+// are refreshed.
+func VerifyEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Token string `json:"token" validate:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, errBadRequest("Invalid input data"))
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			respondError(c, errValidation(err))
+			return
+		}
+		claims, err := utils.RedeemActionToken(req.Token, models.PurposeVerifyEmail)
+		if err != nil {
+			respondActionTokenError(c, err)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		update := bson.M{"$set": bson.M{"email_verified": true, "updated_at": time.Now()}}
+		result, err := userCollection.UpdateOne(ctx, bson.M{"user_id": claims.UID}, update)
+		if err != nil {
+			respondError(c, errInternal("Failed to verify email", err))
+			return
+		}
+		if result.MatchedCount == 0 {
+			respondError(c, errNotFound("User not found"))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Email address verified"})
+	}
+}
+
+// ResendVerificationEmail sends the caller a new verification link; the
+// previous one stops working.
+func ResendVerificationEmail(mail mailer.Mailer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			respondError(c, errUnauthorized("Authentication required"))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var user models.User
+		if err := userCollection.FindOne(ctx, bson.M{"user_id": userID}).Decode(&user); err != nil {
+			respondUserLookupError(c, err)
+			return
+		}
+		if user.EmailVerified {
+			respondError(c, errConflict("Email address is already verified"))
+			return
+		}
+		go sendVerificationEmail(mail, user)
+		c.JSON(http.StatusAccepted, gin.H{"message": "Verification email sent"})
+	}
+}
+
+// MigrateEmailVerified marks users registered before email verification
+// existed as verified.
+func MigrateEmailVerified(ctx context.Context) error {
+	filter := bson.M{"email_verified": bson.M{"$exists": false}}
+	_, err := userCollection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"email_verified": true}})
+	return err
+}
+
+func sendPasswordResetEmail(mail mailer.Mailer, user models.User) {
+	token, err := utils.GenerateActionToken(user.UserID, models.PurposePasswordReset, passwordResetTTL)
+	if err != nil {
+		log.Error().Err(err).Str("userID", user.UserID).Msg("failed to issue password reset token")
+		return
+	}
+	body := fmt.Sprintf("Hi %s,\n\n"+
+		"Someone asked to reset the password of your CoolStream account. Open this link within %d minutes to choose a new one:\n\n"+
+		"%s\n\n"+
+		"If that was not you, ignore this email and your password stays the same.\n",
+		user.FirstName, int(passwordResetTTL.Minutes()), actionLink("/reset-password", token))
+	sendMail(mail, mailer.Message{To: user.Email, Subject: "Reset your CoolStream password", Body: body})
+}
+
+func sendVerificationEmail(mail mailer.Mailer, user models.User) {
+	token, err := utils.GenerateActionToken(user.UserID, models.PurposeVerifyEmail, verifyEmailTTL)
+	if err != nil {
+		log.Error().Err(err).Str("userID", user.UserID).Msg("failed to issue email verification token")
+		return
+	}
+	body := fmt.Sprintf("Hi %s,\n\n"+
+		"Welcome to CoolStream! Confirm your email address within %d hours to unlock your account:\n\n"+
+		"%s\n",
+		user.FirstName, int(verifyEmailTTL.Hours()), actionLink("/verify-email", token))
+	sendMail(mail, mailer.Message{To: user.Email, Subject: "Confirm your email address", Body: body})
+}
+
+func sendMail(mail mailer.Mailer, msg mailer.Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), mailTimeout)
+	defer cancel()
+
+	if err := mail.Send(ctx, msg); err != nil {
+		log.Error().Err(err).Str("subject", msg.Subject).Msg("failed to send mail")
+	}
+}
+
+// actionLink points at the page of the client app, found at APP_URL, that
+// handles the emailed token. The localhost default only suits local
+// development; the SMTP mailer refuses to start without APP_URL.
+func actionLink(path, token string) string {
+	base := os.Getenv("APP_URL")
+	if base == "" {
+		base = "http://localhost:8080"
+	}
+	return strings.TrimRight(base, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+func respondActionTokenError(c *gin.Context, err error) {
+	if errors.Is(err, utils.ErrInvalidActionToken) {
+		respondError(c, errBadRequest("Invalid or expired token"))
+		return
+	}
+	respondError(c, errInternal("Failed to verify token", err))
+}
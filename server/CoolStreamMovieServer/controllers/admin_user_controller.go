@@ -0,0 +1,314 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/database"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/utils"
+)
+
+var auditCollection *mongo.Collection = database.OpenCollection("audit_logs")
+
+func ListUsers() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, limit, err := parsePagination(c)
+		if err != nil {
+			respondError(c, errBadRequest(err.Error()))
+			return
+		}
+
+		filter := bson.M{}
+		if q := c.Query("q"); q != "" {
+			pattern := bson.Regex{Pattern: regexp.QuoteMeta(q), Options: "i"}
+			filter["$or"] = bson.A{
+				bson.M{"email": pattern},
+				bson.M{"first_name": pattern},
+				bson.M{"last_name": pattern},
+			}
+		}
+		if role := c.Query("role"); role != "" {
+			filter["role"] = role
+		}
+		switch c.Query("disabled") {
+		case "true":
+			filter["disabled"] = true
+		case "false":
+			filter["disabled"] = bson.M{"$ne": true}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		total, err := userCollection.CountDocuments(ctx, filter)
+		if err != nil {
+			respondError(c, errInternal("Failed to count users", err))
+			return
+		}
+		findOptions := options.Find().
+			SetSort(bson.D{{Key: "created_at", Value: -1}}).
+			SetSkip((page - 1) * limit).
+			SetLimit(limit)
+		cursor, err := userCollection.Find(ctx, filter, findOptions)
+		if err != nil {
+			respondError(c, errInternal("Failed to fetch users", err))
+			return
+		}
+		defer cursor.Close(ctx)
+
+		var users []models.User
+		if err := cursor.All(ctx, &users); err != nil {
+			respondError(c, errInternal("Failed to decode users", err))
+			return
+		}
+		publicUsers := make([]models.PublicUser, 0, len(users))
+		for _, user := range users {
+			publicUsers = append(publicUsers, toPublicUser(user))
+		}
+
+		c.JSON(http.StatusOK, gin.H{"users": publicUsers, "meta": newPageMeta(page, limit, total)})
+	}
+}
+
+func UpdateUserRole() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorID, targetID, ok := adminTarget(c)
+		if !ok {
+			return
+		}
+		var req struct {
+			Role string `json:"role" validate:"required,oneof=ADMIN USER"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, errBadRequest("Invalid input data"))
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			respondError(c, errValidation(err))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var previous models.User
+		update := bson.M{"$set": bson.M{"role": req.Role, "updated_at": time.Now()}}
+		err := userCollection.FindOneAndUpdate(ctx, bson.M{"user_id": targetID}, update).Decode(&previous)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				respondError(c, errNotFound("User not found"))
+				return
+			}
+			respondError(c, errInternal("Failed to update role", err))
+			return
+		}
+
+		if previous.Role != req.Role {
+			// Tokens carry the role, so the old ones must not outlive it.
+			if err := utils.RevokeAllTokens(targetID); err != nil {
+				respondError(c, errInternal("Failed to revoke tokens", err))
+				return
+			}
+			recordAudit(ctx, models.AuditLog{
+				ActorID:      actorID,
+				TargetUserID: targetID,
+				Action:       models.AuditRoleChanged,
+				OldRole:      previous.Role,
+				NewRole:      req.Role,
+			})
+		}
+
+		previous.Role = req.Role
+		c.JSON(http.StatusOK, toPublicUser(previous))
+	}
+}
+
+func DisableUser() gin.HandlerFunc {
+	return setUserDisabled(true)
+}
+
+func EnableUser() gin.HandlerFunc {
+	return setUserDisabled(false)
+}
+
+func setUserDisabled(disabled bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorID, targetID, ok := adminTarget(c)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var user models.User
+		update := bson.M{"$set": bson.M{"disabled": disabled, "updated_at": time.Now()}}
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+		err := userCollection.FindOneAndUpdate(ctx, bson.M{"user_id": targetID}, update, opts).Decode(&user)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				respondError(c, errNotFound("User not found"))
+				return
+			}
+			respondError(c, errInternal("Failed to update user", err))
+			return
+		}
+
+		action := models.AuditUserEnabled
+		if disabled {
+			action = models.AuditUserDisabled
+			if err := utils.RevokeAllTokens(targetID); err != nil {
+				respondError(c, errInternal("Failed to revoke tokens", err))
+				return
+			}
+		}
+		recordAudit(ctx, models.AuditLog{ActorID: actorID, TargetUserID: targetID, Action: action})
+
+		c.JSON(http.StatusOK, toPublicUser(user))
+	}
+}
+
+func DeleteUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorID, targetID, ok := adminTarget(c)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		if err := utils.RevokeAllTokens(targetID); err != nil {
+			respondError(c, errInternal("Failed to revoke tokens", err))
+			return
+		}
+		var deleted models.User
+		err := userCollection.FindOneAndDelete(ctx, bson.M{"user_id": targetID}).Decode(&deleted)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				respondError(c, errNotFound("User not found"))
+				return
+			}
+			respondError(c, errInternal("Failed to delete user", err))
+			return
+		}
+		recordAudit(ctx, models.AuditLog{
+			ActorID:      actorID,
+			TargetUserID: targetID,
+			Action:       models.AuditUserDeleted,
+			OldRole:      deleted.Role,
+		})
+		if _, err := watchlistCollection.DeleteMany(ctx, bson.M{"user_id": targetID}); err != nil {
+			log.Error().Err(err).Str("userID", targetID).Msg("failed to delete watchlist of deleted user")
+		}
+		if _, err := watchProgressCollection.DeleteMany(ctx, bson.M{"user_id": targetID}); err != nil {
+			log.Error().Err(err).Str("userID", targetID).Msg("failed to delete watch progress of deleted user")
+		}
+		if _, err := watchEventCollection.DeleteMany(ctx, bson.M{"meta.user_id": targetID}); err != nil {
+			log.Error().Err(err).Str("userID", targetID).Msg("failed to delete watch events of deleted user")
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func ListAuditLogs() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, limit, err := parsePagination(c)
+		if err != nil {
+			respondError(c, errBadRequest(err.Error()))
+			return
+		}
+		filter := bson.M{}
+		if targetID := c.Query("target_user_id"); targetID != "" {
+			filter["target_user_id"] = targetID
+		}
+		if actorID := c.Query("actor_id"); actorID != "" {
+			filter["actor_id"] = actorID
+		}
+		if action := c.Query("action"); action != "" {
+			filter["action"] = action
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		total, err := auditCollection.CountDocuments(ctx, filter)
+		if err != nil {
+			respondError(c, errInternal("Failed to count audit logs", err))
+			return
+		}
+		findOptions := options.Find().
+			SetSort(bson.D{{Key: "created_at", Value: -1}}).
+			SetSkip((page - 1) * limit).
+			SetLimit(limit)
+		cursor, err := auditCollection.Find(ctx, filter, findOptions)
+		if err != nil {
+			respondError(c, errInternal("Failed to fetch audit logs", err))
+			return
+		}
+		defer cursor.Close(ctx)
+
+		logs := []models.AuditLog{}
+		if err := cursor.All(ctx, &logs); err != nil {
+			respondError(c, errInternal("Failed to decode audit logs", err))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"audit_logs": logs, "meta": newPageMeta(page, limit, total)})
+	}
+}
+
+// adminTarget resolves the acting admin and the :user_id being managed. It
+// writes the error response itself and refuses to let admins manage their
+// own account, so that the last admin cannot lock everybody out.
+func adminTarget(c *gin.Context) (string, string, bool) {
+	actorID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		respondError(c, errUnauthorized(err.Error()))
+		return "", "", false
+	}
+	targetID := c.Param("user_id")
+	if targetID == "" {
+		respondError(c, errBadRequest("user_id is required"))
+		return "", "", false
+	}
+	if targetID == actorID {
+		respondError(c, errForbidden("Admins cannot manage their own account"))
+		return "", "", false
+	}
+	return actorID, targetID, true
+}
+
+// recordAudit stores an audit entry. A failure is logged rather than
+// returned: the change it describes has already been applied.
+func recordAudit(ctx context.Context, entry models.AuditLog) {
+	entry.CreatedAt = time.Now()
+	if _, err := auditCollection.InsertOne(ctx, entry); err != nil {
+		log.Error().Err(err).
+			Str("actorID", entry.ActorID).
+			Str("targetUserID", entry.TargetUserID).
+			Str("action", entry.Action).
+			Msg("failed to record audit log")
+	}
+}
+
+func toPublicUser(user models.User) models.PublicUser {
+	return models.PublicUser{
+		UserID:         user.UserID,
+		FirstName:      user.FirstName,
+		LastName:       user.LastName,
+		Email:          user.Email,
+		Role:           user.Role,
+		Disabled:       user.Disabled,
+		EmailVerified:  user.EmailVerified,
+		CreatedAt:      user.CreatedAt,
+		UpdatedAt:      user.UpdatedAt,
+		FavoriteGenres: user.FavoriteGenres,
+	}
+}
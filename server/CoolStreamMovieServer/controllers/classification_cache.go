@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/cache"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/database"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/llm"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+)
+
+// rankingsCacheTTL bounds how long another server instance may serve a
+// ranking scale changed elsewhere; changes made here invalidate at once.
+const rankingsCacheTTL = time.Minute
+
+const rankingsCacheKey = "rankings"
+
+var (
+	classificationCacheCollection *mongo.Collection = database.OpenCollection("classification_cache")
+	rankingsCache                                   = cache.NewTTL[string, []models.Ranking](rankingsCacheTTL)
+)
+
+// invalidateRankings drops the cached ranking scale after it changed.
+func invalidateRankings() {
+	rankingsCache.Delete(rankingsCacheKey)
+}
+
+type classificationStore struct{}
+
+// NewClassificationStore persists cached classifications in the
+// classification_cache collection.
+func NewClassificationStore() llm.ClassificationStore {
+	return classificationStore{}
+}
+
+func (classificationStore) Load(ctx context.Context, key string) (llm.Classification, bool, error) {
+	var entry models.CachedClassification
+	err := classificationCacheCollection.FindOne(ctx, bson.M{"_id": key}).Decode(&entry)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return llm.Classification{}, false, nil
+	}
+	if err != nil {
+		return llm.Classification{}, false, err
+	}
+	return llm.Classification{Ranking: entry.Ranking, Model: entry.Model, PromptVersion: entry.PromptVersion}, true, nil
+}
+
+func (classificationStore) Save(ctx context.Context, key string, classification llm.Classification) error {
+	entry := models.CachedClassification{
+		Key:           key,
+		Ranking:       classification.Ranking,
+		Model:         classification.Model,
+		PromptVersion: classification.PromptVersion,
+		CreatedAt:     time.Now(),
+	}
+	_, err := classificationCacheCollection.ReplaceOne(ctx, bson.M{"_id": key}, entry, options.Replace().SetUpsert(true))
+	return err
+}
+
+// cachedRankings returns a copy of the cached ranking scale, so callers may
+// reorder it.
+func cachedRankings() ([]models.Ranking, bool) {
+	rankings, ok := rankingsCache.Get(rankingsCacheKey)
+	return slices.Clone(rankings), ok
+}
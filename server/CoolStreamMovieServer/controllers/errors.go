@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const (
+	codeBadRequest           = "bad_request"
+	codeValidationFailed     = "validation_failed"
+	codeUnauthorized         = "unauthorized"
+	codeForbidden            = "forbidden"
+	codeNotFound             = "not_found"
+	codeConflict             = "conflict"
+	codeTooManyRequests      = "too_many_requests"
+	codePreconditionRequired = "precondition_required"
+	codeInternal             = "internal_error"
+	codeBadGateway           = "bad_gateway"
+)
+
+// APIError is the JSON error envelope every controller responds with:
+// {"error": "<message>", "code": "<code>", "details": ...}.
+type APIError struct {
+	Status  int    `json:"-"`
+	Code    string `json:"code"`
+	Message string `json:"error"`
+	Details any    `json:"details,omitempty"`
+	cause   error
+}
+
+func (e *APIError) Error() string {
+	if e.cause != nil {
+		return e.Message + ": " + e.cause.Error()
+	}
+	return e.Message
+}
+
+func (e *APIError) Unwrap() error {
+	return e.cause
+}
+
+type fieldError struct {
+	Field string `json:"field"`
+	Rule  string `json:"rule"`
+	Param string `json:"param,omitempty"`
+}
+
+func errBadRequest(message string) *APIError {
+	return &APIError{Status: http.StatusBadRequest, Code: codeBadRequest, Message: message}
+}
+
+// errValidation describes every failed validator rule when err comes from
+// validate.Struct, and falls back to err's text otherwise.
+func errValidation(err error) *APIError {
+	apiErr := &APIError{Status: http.StatusBadRequest, Code: codeValidationFailed, Message: "Validation failed", cause: err}
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		fields := make([]fieldError, 0, len(validationErrors))
+		for _, fe := range validationErrors {
+			fields = append(fields, fieldError{Field: fe.Namespace(), Rule: fe.Tag(), Param: fe.Param()})
+		}
+		apiErr.Details = fields
+	} else if err != nil {
+		apiErr.Details = err.Error()
+	}
+	return apiErr
+}
+
+func errUnauthorized(message string) *APIError {
+	return &APIError{Status: http.StatusUnauthorized, Code: codeUnauthorized, Message: message}
+}
+
+func errForbidden(message string) *APIError {
+	return &APIError{Status: http.StatusForbidden, Code: codeForbidden, Message: message}
+}
+
+func errNotFound(message string) *APIError {
+	return &APIError{Status: http.StatusNotFound, Code: codeNotFound, Message: message}
+}
+
+func errConflict(message string) *APIError {
+	return &APIError{Status: http.StatusConflict, Code: codeConflict, Message: message}
+}
+
+func errTooManyRequests(message string) *APIError {
+	return &APIError{Status: http.StatusTooManyRequests, Code: codeTooManyRequests, Message: message}
+}
+
+func errPreconditionRequired(message string) *APIError {
+	return &APIError{Status: http.StatusPreconditionRequired, Code: codePreconditionRequired, Message: message}
+}
+
+// errInternal hides cause from the client; respondError logs it.
+func errInternal(message string, cause error) *APIError {
+	return &APIError{Status: http.StatusInternalServerError, Code: codeInternal, Message: message, cause: cause}
+}
+
+// errBadGateway reports that an upstream service answered with something
+// unusable. Like errInternal it keeps cause from the client.
+func errBadGateway(message string, cause error) *APIError {
+	return &APIError{Status: http.StatusBadGateway, Code: codeBadGateway, Message: message, cause: cause}
+}
+
+func (e *APIError) withDetails(details any) *APIError {
+	e.Details = details
+	return e
+}
+
+// respondError writes err as an APIError and aborts the request. Errors that
+// are not already APIErrors are mapped by kind: a missing document is a 404,
+// a duplicate key a 409, failed validation a 400 and anything else a 500.
+func respondError(c *gin.Context, err error) {
+	apiErr := toAPIError(err)
+	if apiErr.Status >= http.StatusInternalServerError {
+		log.Error().Err(apiErr.cause).
+			Str("method", c.Request.Method).
+			Str("path", c.FullPath()).
+			Msg(apiErr.Message)
+	}
+	c.AbortWithStatusJSON(apiErr.Status, apiErr)
+}
+
+func toAPIError(err error) *APIError {
+	var apiErr *APIError
+	var validationErrors validator.ValidationErrors
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return errNotFound("Resource not found")
+	case mongo.IsDuplicateKeyError(err):
+		return errConflict("Resource already exists")
+	case errors.As(err, &validationErrors):
+		return errValidation(err)
+	default:
+		return errInternal("Internal server error", err)
+	}
+}
@@ -0,0 +1,281 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/database"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+)
+
+var genreCollection *mongo.Collection = database.OpenCollection("genres")
+
+func GetGenres() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		opts := options.Find().SetSort(bson.D{{Key: "genre_id", Value: 1}})
+		cursor, err := genreCollection.Find(ctx, bson.M{}, opts)
+		if err != nil {
+			respondError(c, errInternal("Failed to fetch genres", err))
+			return
+		}
+		defer cursor.Close(ctx)
+
+		genres := []models.Genre{}
+		if err := cursor.All(ctx, &genres); err != nil {
+			respondError(c, errInternal("Failed to decode genres", err))
+			return
+		}
+		c.JSON(http.StatusOK, genres)
+	}
+}
+
+func AddGenre() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var genre models.Genre
+		if err := c.ShouldBindJSON(&genre); err != nil {
+			respondError(c, errBadRequest("Invalid input"))
+			return
+		}
+		genre.GenreName = strings.TrimSpace(genre.GenreName)
+		if err := validate.Struct(genre); err != nil {
+			respondError(c, errValidation(err))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		if _, err := genreCollection.InsertOne(ctx, genre); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				respondError(c, errConflict("A genre with this id or name already exists"))
+				return
+			}
+			respondError(c, errInternal("Failed to add genre", err))
+			return
+		}
+		c.JSON(http.StatusCreated, genre)
+	}
+}
+
+// RenameGenre answers PUT /genres/:genre_id. The new name is copied into
+// every movie and every user's favourite genres that embed the genre.
+func RenameGenre() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		genreID, err := strconv.Atoi(c.Param("genre_id"))
+		if err != nil {
+			respondError(c, errBadRequest("genre_id must be an integer"))
+			return
+		}
+		var req struct {
+			GenreName string `json:"genre_name" validate:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, errBadRequest("Invalid input"))
+			return
+		}
+		req.GenreName = strings.TrimSpace(req.GenreName)
+		if err := validate.Struct(req); err != nil {
+			respondError(c, errValidation(err))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		update := bson.M{"$set": bson.M{"genre_name": req.GenreName}}
+		result, err := genreCollection.UpdateOne(ctx, bson.M{"genre_id": genreID}, update)
+		if err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				respondError(c, errConflict("A genre with this name already exists"))
+				return
+			}
+			respondError(c, errInternal("Failed to rename genre", err))
+			return
+		}
+		if result.MatchedCount == 0 {
+			respondError(c, errNotFound("Genre not found"))
+			return
+		}
+
+		moviesUpdated, usersUpdated, err := cascadeGenreRename(ctx, genreID, req.GenreName)
+		if err != nil {
+			respondError(c, errInternal("Genre renamed but updating its copies failed", err))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"genre":          models.Genre{GenreID: genreID, GenreName: req.GenreName},
+			"movies_updated": moviesUpdated,
+			"users_updated":  usersUpdated,
+		})
+	}
+}
+
+// DeleteGenre refuses to delete a genre that movies or users still embed.
+func DeleteGenre() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		genreID, err := strconv.Atoi(c.Param("genre_id"))
+		if err != nil {
+			respondError(c, errBadRequest("genre_id must be an integer"))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		movieCount, err := movieCollection.CountDocuments(ctx, bson.M{"genre.genre_id": genreID})
+		if err != nil {
+			respondError(c, errInternal("Failed to check genre usage", err))
+			return
+		}
+		userCount, err := userCollection.CountDocuments(ctx, bson.M{"favourite_genres.genre_id": genreID})
+		if err != nil {
+			respondError(c, errInternal("Failed to check genre usage", err))
+			return
+		}
+		if movieCount > 0 || userCount > 0 {
+			respondError(c, errConflict("Genre is still in use").withDetails(gin.H{
+				"movies": movieCount,
+				"users":  userCount,
+			}))
+			return
+		}
+
+		result, err := genreCollection.DeleteOne(ctx, bson.M{"genre_id": genreID})
+		if err != nil {
+			respondError(c, errInternal("Failed to delete genre", err))
+			return
+		}
+		if result.DeletedCount == 0 {
+			respondError(c, errNotFound("Genre not found"))
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func cascadeGenreRename(ctx context.Context, genreID int, genreName string) (int64, int64, error) {
+	movieResult, err := movieCollection.UpdateMany(ctx,
+		bson.M{"genre.genre_id": genreID},
+		bson.M{
+			"$set": bson.M{"genre.$[g].genre_name": genreName},
+			"$inc": bson.M{"version": 1},
+		},
+		options.UpdateMany().SetArrayFilters([]any{bson.M{"g.genre_id": genreID}}),
+	)
+	if err != nil {
+		return 0, 0, err
+	}
+	userResult, err := userCollection.UpdateMany(ctx,
+		bson.M{"favourite_genres.genre_id": genreID},
+		bson.M{"$set": bson.M{"favourite_genres.$[g].genre_name": genreName}},
+		options.UpdateMany().SetArrayFilters([]any{bson.M{"g.genre_id": genreID}}),
+	)
+	if err != nil {
+		return movieResult.ModifiedCount, 0, err
+	}
+	return movieResult.ModifiedCount, userResult.ModifiedCount, nil
+}
+
+// loadGenres returns the name of every genre matching filter by id.
+func loadGenres(ctx context.Context, filter bson.M) (map[int]string, error) {
+	cursor, err := genreCollection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var genres []models.Genre
+	if err := cursor.All(ctx, &genres); err != nil {
+		return nil, err
+	}
+	known := make(map[int]string, len(genres))
+	for _, genre := range genres {
+		known[genre.GenreID] = genre.GenreName
+	}
+	return known, nil
+}
+
+// validateGenres checks that every genre exists in the genres collection
+// under the same name.
+func validateGenres(ctx context.Context, genres []models.Genre) error {
+	if len(genres) == 0 {
+		return nil
+	}
+	ids := make([]int, 0, len(genres))
+	for _, genre := range genres {
+		ids = append(ids, genre.GenreID)
+	}
+	known, err := loadGenres(ctx, bson.M{"genre_id": bson.M{"$in": ids}})
+	if err != nil {
+		return errInternal("Failed to validate genres", err)
+	}
+	return checkGenres(known, genres)
+}
+
+func checkGenres(known map[int]string, genres []models.Genre) error {
+	var problems []string
+	for _, genre := range genres {
+		name, ok := known[genre.GenreID]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("genre_id %d does not exist", genre.GenreID))
+		case name != genre.GenreName:
+			problems = append(problems, fmt.Sprintf("genre_id %d is named %q, not %q", genre.GenreID, name, genre.GenreName))
+		}
+	}
+	if len(problems) > 0 {
+		return errValidation(errors.New(strings.Join(problems, "; "))).withDetails(problems)
+	}
+	return nil
+}
+
+// SeedGenresFromMovies fills an empty genres collection with the distinct
+// genres already embedded in movies, so that existing catalogs keep passing
+// genre validation.
+func SeedGenresFromMovies(ctx context.Context) error {
+	count, err := genreCollection.EstimatedDocumentCount(ctx)
+	if err != nil || count > 0 {
+		return err
+	}
+	pipeline := bson.A{
+		bson.M{"$unwind": "$genre"},
+		bson.M{"$group": bson.M{"_id": "$genre.genre_id", "genre_name": bson.M{"$first": "$genre.genre_name"}}},
+		bson.M{"$project": bson.M{"_id": 0, "genre_id": "$_id", "genre_name": 1}},
+	}
+	cursor, err := movieCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var genres []models.Genre
+	if err := cursor.All(ctx, &genres); err != nil {
+		return err
+	}
+	if len(genres) == 0 {
+		return nil
+	}
+	docs := make([]any, 0, len(genres))
+	for _, genre := range genres {
+		docs = append(docs, genre)
+	}
+	_, err = genreCollection.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+	log.Info().Int("count", len(genres)).Msg("seeded genres from movies")
+	return nil
+}
@@ -0,0 +1,208 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/database"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+)
+
+var loginAttemptCollection *mongo.Collection = database.OpenCollection("login_attempts")
+
+const (
+	// loginBaseLockout is the first lockout of a key; every further failure
+	// doubles it up to loginMaxLockout.
+	loginBaseLockout = time.Minute
+	loginMaxLockout  = time.Hour
+	// loginAttemptWindow is how long failures are remembered after the last
+	// one.
+	loginAttemptWindow = 24 * time.Hour
+)
+
+// loginLimit is the number of failed logins a scope allows before lockouts
+// start. Many users can share an IP, so it gets more room than an account.
+type loginLimit struct {
+	scope       string
+	maxFailures int
+}
+
+var (
+	accountLoginLimit = loginLimit{scope: "account", maxFailures: 5}
+	ipLoginLimit      = loginLimit{scope: "ip", maxFailures: 20}
+)
+
+type loginKey struct {
+	limit loginLimit
+	key   string
+}
+
+// loginAttempt identifies a login request for throttling and for the
+// security log.
+type loginAttempt struct {
+	email string
+	ip    string
+	keys  []loginKey
+}
+
+func newLoginAttempt(c *gin.Context, email string) loginAttempt {
+	attempt := loginAttempt{email: strings.ToLower(strings.TrimSpace(email)), ip: c.ClientIP()}
+	attempt.keys = []loginKey{
+		{limit: accountLoginLimit, key: accountLoginKey(attempt.email)},
+		{limit: ipLoginLimit, key: "ip:" + attempt.ip},
+	}
+	return attempt
+}
+
+func accountLoginKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// dummyPasswordHash is compared against when the email is unknown, so that
+// a login takes as long whether or not the account exists.
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, err := bcrypt.GenerateFromPassword([]byte("coolstream-dummy-password"), bcrypt.DefaultCost)
+	if err != nil {
+		panic(err)
+	}
+	return hash
+})
+
+// respondIfLockedOut writes a 429 with Retry-After and reports true when any
+// key of attempt is locked out.
+func respondIfLockedOut(ctx context.Context, c *gin.Context, attempt loginAttempt) bool {
+	keys := make([]string, 0, len(attempt.keys))
+	for _, key := range attempt.keys {
+		keys = append(keys, key.key)
+	}
+	now := time.Now()
+	filter := bson.M{"key": bson.M{"$in": keys}, "locked_until": bson.M{"$gt": now}}
+	cursor, err := loginAttemptCollection.Find(ctx, filter)
+	if err != nil {
+		respondError(c, errInternal("Failed to check login attempts", err))
+		return true
+	}
+	var locked []models.LoginAttempt
+	if err := cursor.All(ctx, &locked); err != nil {
+		respondError(c, errInternal("Failed to check login attempts", err))
+		return true
+	}
+	var wait time.Duration
+	for _, lock := range locked {
+		wait = max(wait, lock.LockedUntil.Sub(now))
+	}
+	if wait <= 0 {
+		return false
+	}
+	seconds := int((wait + time.Second - 1) / time.Second)
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	respondError(c, errTooManyRequests("Too many failed login attempts, try again later"))
+	return true
+}
+
+// recordLoginFailure counts a failed login against every key of attempt and
+// locks out the keys over their limit. Failures are logged rather than
+// returned: the login has failed either way.
+func recordLoginFailure(ctx context.Context, attempt loginAttempt) {
+	now := time.Now()
+	update := bson.M{
+		"$inc": bson.M{"failures": 1},
+		"$set": bson.M{"last_failure_at": now, "expires_at": now.Add(loginAttemptWindow)},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	for _, key := range attempt.keys {
+		var counter models.LoginAttempt
+		err := loginAttemptCollection.FindOneAndUpdate(ctx, bson.M{"key": key.key}, update, opts).Decode(&counter)
+		if err != nil {
+			log.Error().Err(err).Str("scope", key.limit.scope).Msg("failed to record login failure")
+			continue
+		}
+		lockout := loginLockout(counter.Failures, key.limit.maxFailures)
+		if lockout == 0 {
+			continue
+		}
+		lock := bson.M{"$max": bson.M{"locked_until": now.Add(lockout)}}
+		if _, err := loginAttemptCollection.UpdateOne(ctx, bson.M{"key": key.key}, lock); err != nil {
+			log.Error().Err(err).Str("scope", key.limit.scope).Msg("failed to lock out login")
+			continue
+		}
+		log.Warn().
+			Str("scope", key.limit.scope).
+			Str("email", attempt.email).
+			Str("ip", attempt.ip).
+			Int("failures", counter.Failures).
+			Dur("lockout", lockout).
+			Msg("login locked out")
+	}
+}
+
+// clearLoginFailures forgets the failed logins of the account of attempt
+// after it logged in. The IP keeps its count, so that an attacker cannot
+// reset it with an account of their own.
+func clearLoginFailures(ctx context.Context, attempt loginAttempt) {
+	var counter models.LoginAttempt
+	err := loginAttemptCollection.FindOneAndDelete(ctx, bson.M{"key": attempt.keys[0].key}).Decode(&counter)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			log.Error().Err(err).Msg("failed to clear login failures")
+		}
+		return
+	}
+	if counter.LockedUntil != nil {
+		log.Info().Str("scope", accountLoginLimit.scope).Str("email", attempt.email).Str("ip", attempt.ip).Msg("login lockout cleared after successful login")
+	}
+}
+
+// loginLockout is how long a key is locked out after failures failed logins:
+// nothing up to maxFailures, then loginBaseLockout doubling with every
+// failure.
+func loginLockout(failures, maxFailures int) time.Duration {
+	if failures < maxFailures {
+		return 0
+	}
+	lockout := loginBaseLockout
+	for i := maxFailures; i < failures && lockout < loginMaxLockout; i++ {
+		lockout *= 2
+	}
+	return min(lockout, loginMaxLockout)
+}
+
+// UnlockUser lifts the login lockout of a user's account before it runs out.
+func UnlockUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorID, targetID, ok := adminTarget(c)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var user models.User
+		if err := userCollection.FindOne(ctx, bson.M{"user_id": targetID}).Decode(&user); err != nil {
+			respondUserLookupError(c, err)
+			return
+		}
+		result, err := loginAttemptCollection.DeleteOne(ctx, bson.M{"key": accountLoginKey(user.Email)})
+		if err != nil {
+			respondError(c, errInternal("Failed to unlock user", err))
+			return
+		}
+		if result.DeletedCount > 0 {
+			log.Info().Str("scope", accountLoginLimit.scope).Str("email", user.Email).Str("actorID", actorID).Msg("login lockout cleared by admin")
+		}
+		recordAudit(ctx, models.AuditLog{ActorID: actorID, TargetUserID: targetID, Action: models.AuditUserUnlocked})
+
+		c.JSON(http.StatusOK, toPublicUser(user))
+	}
+}
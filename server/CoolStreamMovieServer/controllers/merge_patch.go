@@ -0,0 +1,23 @@
+package controllers
+
+// mergePatch applies an RFC 7386 JSON merge patch to target, both decoded
+// with encoding/json: objects are merged recursively, null removes a member
+// and any other value replaces the target wholesale.
+func mergePatch(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = map[string]any{}
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+	return targetObject
+}
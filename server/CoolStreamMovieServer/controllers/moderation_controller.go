@@ -0,0 +1,264 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/utils"
+)
+
+const (
+	decisionApprove = "approve"
+	decisionReject  = "reject"
+	decisionBan     = "ban"
+)
+
+// ListModerationQueue lists reviews by status, by default the flagged ones
+// waiting for a decision, oldest first.
+func ListModerationQueue() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, limit, err := parsePagination(c)
+		if err != nil {
+			respondError(c, errBadRequest(err.Error()))
+			return
+		}
+		filter := bson.M{"status": c.DefaultQuery("status", models.ReviewFlagged)}
+		if userID := c.Query("user_id"); userID != "" {
+			filter["user_id"] = userID
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		total, err := reviewCollection.CountDocuments(ctx, filter)
+		if err != nil {
+			respondError(c, errInternal("Failed to count reviews", err))
+			return
+		}
+		findOptions := options.Find().
+			SetSort(bson.D{{Key: "created_at", Value: 1}}).
+			SetSkip((page - 1) * limit).
+			SetLimit(limit)
+		cursor, err := reviewCollection.Find(ctx, filter, findOptions)
+		if err != nil {
+			respondError(c, errInternal("Failed to fetch reviews", err))
+			return
+		}
+		defer cursor.Close(ctx)
+
+		reviews := []models.Review{}
+		if err := cursor.All(ctx, &reviews); err != nil {
+			respondError(c, errInternal("Failed to decode reviews", err))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"reviews": reviews, "meta": newPageMeta(page, limit, total)})
+	}
+}
+
+// ApproveReview publishes a review.
+func ApproveReview() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorID, reviewID, reason, ok := moderationRequest(c)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		review, err := decideReview(ctx, reviewID, models.ReviewPublished, decisionApprove, actorID, reason)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		recordAudit(ctx, models.AuditLog{
+			ActorID:      actorID,
+			TargetUserID: review.UserID,
+			Action:       models.AuditReviewApproved,
+			ReviewID:     review.ID.Hex(),
+			Reason:       reason,
+		})
+		c.JSON(http.StatusOK, review)
+	}
+}
+
+// RejectReview hides a review for good. It stays stored as a record of the
+// decision.
+func RejectReview() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorID, reviewID, reason, ok := moderationRequest(c)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		review, err := decideReview(ctx, reviewID, models.ReviewRejected, decisionReject, actorID, reason)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		recordAudit(ctx, models.AuditLog{
+			ActorID:      actorID,
+			TargetUserID: review.UserID,
+			Action:       models.AuditReviewRejected,
+			ReviewID:     review.ID.Hex(),
+			Reason:       reason,
+		})
+		c.JSON(http.StatusOK, review)
+	}
+}
+
+// BanReviewAuthor rejects a review, disables its author and signs them out
+// everywhere. The author's other flagged reviews are rejected too.
+func BanReviewAuthor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorID, reviewID, reason, ok := moderationRequest(c)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var target models.Review
+		if err := reviewCollection.FindOne(ctx, bson.M{"_id": reviewID}).Decode(&target); err != nil {
+			respondReviewLookupError(c, err)
+			return
+		}
+		if target.UserID == actorID {
+			respondError(c, errForbidden("Admins cannot manage their own account"))
+			return
+		}
+		var author models.User
+		update := bson.M{"$set": bson.M{"disabled": true, "updated_at": time.Now()}}
+		filter := bson.M{"user_id": target.UserID, "role": bson.M{"$ne": models.RoleAdmin}}
+		if err := userCollection.FindOneAndUpdate(ctx, filter, update).Decode(&author); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				respondError(c, errConflict("The author is an admin or no longer exists"))
+				return
+			}
+			respondError(c, errInternal("Failed to disable user", err))
+			return
+		}
+		if err := utils.RevokeAllTokens(author.UserID); err != nil {
+			respondError(c, errInternal("Failed to revoke tokens", err))
+			return
+		}
+		recordAudit(ctx, models.AuditLog{
+			ActorID:      actorID,
+			TargetUserID: author.UserID,
+			Action:       models.AuditUserBanned,
+			ReviewID:     reviewID.Hex(),
+			Reason:       reason,
+		})
+
+		review, err := decideReview(ctx, reviewID, models.ReviewRejected, decisionBan, actorID, reason)
+		if err != nil && !errors.Is(err, errReviewAlreadyDecided) {
+			respondError(c, err)
+			return
+		}
+		rejectFlaggedReviews(ctx, author.UserID, actorID, reason)
+		if err != nil {
+			review = target
+		}
+		c.JSON(http.StatusOK, gin.H{"review": review, "user": toPublicUser(author)})
+	}
+}
+
+var errReviewAlreadyDecided = errConflict("Review already has this status")
+
+// decideReview moves a review to status, records the decision on it and
+// updates the movie's ratings. It returns the updated review.
+func decideReview(ctx context.Context, reviewID bson.ObjectID, status, decision, actorID, reason string) (models.Review, error) {
+	now := time.Now()
+	update := bson.M{"$set": bson.M{
+		"status":                status,
+		"moderation.decision":   decision,
+		"moderation.reason":     reason,
+		"moderation.decided_by": actorID,
+		"moderation.decided_at": now,
+	}}
+	var review models.Review
+	filter := bson.M{"_id": reviewID, "status": bson.M{"$ne": status}}
+	err := reviewCollection.FindOneAndUpdate(ctx, filter, update).Decode(&review)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		count, countErr := reviewCollection.CountDocuments(ctx, bson.M{"_id": reviewID})
+		if countErr != nil {
+			return review, errInternal("Failed to fetch review", countErr)
+		}
+		if count == 0 {
+			return review, errNotFound("Review not found")
+		}
+		return review, errReviewAlreadyDecided
+	}
+	if err != nil {
+		return review, errInternal("Failed to update review", err)
+	}
+
+	previous := review
+	review.Status = status
+	if review.Moderation == nil {
+		review.Moderation = &models.ReviewModeration{}
+	}
+	review.Moderation.Decision, review.Moderation.Reason = decision, reason
+	review.Moderation.DecidedBy, review.Moderation.DecidedAt = actorID, &now
+	applyReviewChange(ctx, &previous, &review)
+	return review, nil
+}
+
+// rejectFlaggedReviews rejects every flagged review of a banned user. Flagged
+// reviews do not count towards ratings, so no movie changes.
+func rejectFlaggedReviews(ctx context.Context, userID, actorID, reason string) {
+	update := bson.M{"$set": bson.M{
+		"status":                models.ReviewRejected,
+		"moderation.decision":   decisionBan,
+		"moderation.reason":     reason,
+		"moderation.decided_by": actorID,
+		"moderation.decided_at": time.Now(),
+	}}
+	filter := bson.M{"user_id": userID, "status": models.ReviewFlagged}
+	if _, err := reviewCollection.UpdateMany(ctx, filter, update); err != nil {
+		log.Error().Err(err).Str("userID", userID).Msg("failed to reject reviews of banned user")
+	}
+}
+
+// moderationRequest reads the acting admin, the :review_id and the optional
+// {"reason": "..."} body of a moderation decision.
+func moderationRequest(c *gin.Context) (string, bson.ObjectID, string, bool) {
+	actorID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		respondError(c, errUnauthorized(err.Error()))
+		return "", bson.ObjectID{}, "", false
+	}
+	reviewID, err := bson.ObjectIDFromHex(c.Param("review_id"))
+	if err != nil {
+		respondError(c, errBadRequest("review_id is not a valid id"))
+		return "", bson.ObjectID{}, "", false
+	}
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, errBadRequest("Invalid request body"))
+			return "", bson.ObjectID{}, "", false
+		}
+	}
+	return actorID, reviewID, strings.TrimSpace(req.Reason), true
+}
+
+func respondReviewLookupError(c *gin.Context, err error) {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		respondError(c, errNotFound("Review not found"))
+		return
+	}
+	respondError(c, errInternal("Failed to fetch review", err))
+}
@@ -6,21 +6,21 @@ import (
 	"errors"
 	"net/http"
 	"os"
+	"slices"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"github.com/joho/godotenv"
-	"github.com/tmc/langchaingo/llms/openai"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 
 	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/database"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/llm"
 	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/logger"
 	models "github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/textutil"
 	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/utils"
 )
 
@@ -31,24 +31,106 @@ var (
 	log                                 = logger.GetLogger()
 )
 
+// GetMovies lists the catalog one page at a time. It pages by page number
+// unless a cursor is given, in which case it pages by keyset on the sort key.
 func GetMovies() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		query, err := parseMovieQuery(c)
+		if err != nil {
+			respondError(c, errBadRequest(err.Error()))
+			return
+		}
+		page, limit, err := parsePagination(c)
+		if err != nil {
+			respondError(c, errBadRequest(err.Error()))
+			return
+		}
+
+		var pageCursor *movieCursor
+		if cursorStr := c.Query("cursor"); cursorStr != "" {
+			decoded, err := query.decodeCursor(cursorStr)
+			if err != nil {
+				respondError(c, errBadRequest(err.Error()))
+				return
+			}
+			pageCursor = &decoded
+		}
+
 		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
-		var movies []models.Movie
 
-		cursor, err := movieCollection.Find(ctx, bson.M{})
+		total, err := movieCollection.CountDocuments(ctx, query.filter)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch movies"})
+			respondError(c, errInternal("Failed to count movies", err))
+			return
 		}
 
+		filter := query.filter
+		backwards := pageCursor != nil && pageCursor.Direction == cursorPrev
+		// One extra document tells whether another page follows.
+		findOptions := options.Find().SetSort(query.sort(backwards)).SetLimit(limit + 1)
+		if pageCursor != nil {
+			filter = query.keysetFilter(*pageCursor)
+		} else {
+			findOptions.SetSkip((page - 1) * limit)
+		}
+
+		cursor, err := movieCollection.Find(ctx, filter, findOptions)
+		if err != nil {
+			respondError(c, errInternal("Failed to fetch movies", err))
+			return
+		}
 		defer cursor.Close(ctx)
 
+		movies := []models.Movie{}
 		if err := cursor.All(ctx, &movies); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode moveis."})
+			respondError(c, errInternal("Failed to decode movies", err))
+			return
+		}
+		hasMore := int64(len(movies)) > limit
+		if hasMore {
+			movies = movies[:limit]
+		}
+		if backwards {
+			slices.Reverse(movies)
+		}
+
+		var meta models.PageMeta
+		var links models.PageLinks
+		hasNext, hasPrev := hasMore, page > 1
+		if pageCursor != nil {
+			meta = models.PageMeta{Limit: limit, TotalCount: total}
+			if backwards {
+				hasNext, hasPrev = true, hasMore
+			} else {
+				hasNext, hasPrev = hasMore, true
+			}
+		} else {
+			meta = newPageMeta(page, limit, total)
+			if hasNext {
+				links.Next = pageLink(c, map[string]string{"page": strconv.FormatInt(page+1, 10)})
+			}
+			if hasPrev {
+				links.Prev = pageLink(c, map[string]string{"page": strconv.FormatInt(page-1, 10)})
+			}
+		}
+		if len(movies) > 0 {
+			first, last := movies[0], movies[len(movies)-1]
+			if hasNext {
+				meta.NextCursor = query.encodeCursor(cursorNext, movieSortKey(last, query), last.ID)
+			}
+			if pageCursor != nil {
+				if hasNext {
+					links.Next = pageLink(c, map[string]string{"cursor": meta.NextCursor}, "page")
+				}
+				if hasPrev {
+					prevCursor := query.encodeCursor(cursorPrev, movieSortKey(first, query), first.ID)
+					links.Prev = pageLink(c, map[string]string{"cursor": prevCursor}, "page")
+				}
+			}
 		}
 
-		c.JSON(http.StatusOK, movies)
+		c.JSON(http.StatusOK, gin.H{"movies": movies, "meta": meta, "links": links})
 	}
 }
 
@@ -59,17 +141,22 @@ func GetMovie() gin.HandlerFunc {
 
 		movieID := c.Param("imdb_id")
 		if movieID == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Movie ID is required"})
+			respondError(c, errBadRequest("Movie ID is required"))
 			return
 		}
 
 		var movie models.Movie
 		err := movieCollection.FindOne(ctx, bson.M{"imdb_id": movieID}).Decode(&movie)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "movie not found"})
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				respondError(c, errNotFound("Movie not found"))
+				return
+			}
+			respondError(c, errInternal("Error fetching movie", err))
 			return
 		}
 
+		c.Header("ETag", movieETag(movie.Version))
 		c.JSON(http.StatusOK, movie)
 	}
 }
@@ -82,125 +169,138 @@ func AddMovie() gin.HandlerFunc {
 		var movie models.Movie
 
 		if err := c.ShouldBind(&movie); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			respondError(c, errBadRequest("Invalid input"))
 			return
 		}
 
 		if err := validate.Struct(movie); err != nil {
-			c.JSON(
-				http.StatusBadRequest,
-				gin.H{"error": "Validation failed", "details": err.Error()},
-			)
+			respondError(c, errValidation(err))
+			return
+		}
+		if err := validateGenres(ctx, movie.Genre); err != nil {
+			respondError(c, err)
 			return
 		}
 
+		movie.ID = bson.ObjectID{}
+		movie.Version = 1
+		movie.TitleNgrams = textutil.Trigrams(movie.Title)
+		movie.RatingSum, movie.RatingCount, movie.AverageRating, movie.ReviewCount = 0, 0, 0, 0
 		result, err := movieCollection.InsertOne(ctx, movie)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add movie"})
+			if mongo.IsDuplicateKeyError(err) {
+				respondError(c, errConflict("A movie with this imdb_id already exists"))
+				return
+			}
+			respondError(c, errInternal("Failed to add movie", err))
 			return
 		}
 		c.JSON(http.StatusCreated, result)
 	}
 }
 
+// AdminReviewUpdate stores the admin review of a movie and queues its
+// classification. The movie's ranking_status is pending until a review
+// worker has ranked it; the job can be followed under /reviewjobs/:job_id.
 func AdminReviewUpdate() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		movieID := c.Param("imdb_id")
 		if movieID == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "imdb_id is required"})
+			respondError(c, errBadRequest("imdb_id is required"))
 			return
 		}
 		var req struct {
 			AdminReview string `json:"admin_review"`
 		}
 		var resp struct {
-			RankingName string `json:"ranking_name"`
-			AdminReview string `json:"admin_review"`
+			JobID         string `json:"job_id"`
+			RankingStatus string `json:"ranking_status"`
+			AdminReview   string `json:"admin_review"`
 		}
 		if err := c.ShouldBind(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			respondError(c, errBadRequest("Invalid request body"))
 			return
 		}
-		sentiment, rankVal, err := GetReviewRanking(req.AdminReview)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error getting review ranking"})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		// The job is stored first so that a pending movie always has a job;
+		// if the movie update then misses, the job is dropped instead.
+		jobID := bson.NewObjectID()
+		if _, err := enqueueReviewJob(ctx, jobID, movieID, req.AdminReview); err != nil {
+			respondError(c, errInternal("Error queueing review classification", err))
 			return
 		}
-
 		filter := bson.D{{Key: "imdb_id", Value: movieID}}
 		update := bson.M{
 			"$set": bson.M{
-				"admin_review": req.AdminReview,
-				"ranking": bson.M{
-					"ranking_value": rankVal,
-					"ranking_name":  sentiment,
-				},
+				"admin_review":   req.AdminReview,
+				"ranking_status": models.RankingPending,
+				"review_job_id":  jobID.Hex(),
 			},
+			"$inc": bson.M{"version": 1},
 		}
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
-		defer cancel()
-
 		result, err := movieCollection.UpdateOne(ctx, filter, update)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating movie"})
+			supersedeReviewJob(ctx, jobID)
+			respondError(c, errInternal("Error updating movie", err))
 			return
 		}
 		if result.MatchedCount == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+			supersedeReviewJob(ctx, jobID)
+			respondError(c, errNotFound("Movie not found"))
 			return
 		}
-		resp.RankingName = sentiment
+		releaseReviewJob(ctx, jobID)
+		resp.JobID = jobID.Hex()
+		resp.RankingStatus = models.RankingPending
 		resp.AdminReview = req.AdminReview
 
-		c.JSON(http.StatusOK, resp)
+		c.Header("Location", "/reviewjobs/"+resp.JobID)
+		c.JSON(http.StatusAccepted, resp)
 	}
 }
 
-func GetReviewRanking(adminReview string) (string, int, error) {
+// GetReviewRanking classifies review, written about movie, against the
+// current ranking scale with the active prompt template.
+func GetReviewRanking(ctx context.Context, classifier llm.ReviewClassifier, movie models.Movie, review string) (llm.Classification, error) {
 	rankings, err := GetRankings()
 	if err != nil {
-		return "", 0, err
-	}
-	sentimentDelimited := ""
-	for _, ranking := range rankings {
-		if ranking.RankingValue != 999 {
-			sentimentDelimited = sentimentDelimited + ranking.RankingName + ","
-		}
+		return llm.Classification{}, err
 	}
-	sentimentDelimited = strings.Trim(sentimentDelimited, ",")
-
-	err = godotenv.Load(".env")
+	prompt, err := activePromptTemplate(ctx)
 	if err != nil {
-		log.Warn().Err(err).Msg(".env file not found")
+		return llm.Classification{}, err
 	}
-	openApiKey := os.Getenv("OPENAI_API_KEY")
-	if openApiKey == "" {
-		return "", 0, errors.New("could not read OPENAI_API_KEY")
+	return classifier.Classify(ctx, newClassifyRequest(movie, review, rankings, prompt))
+}
+
+func newClassifyRequest(movie models.Movie, review string, rankings []models.Ranking, prompt *models.PromptTemplate) llm.ClassifyRequest {
+	req := llm.ClassifyRequest{Review: review, Title: movie.Title, Rankings: rankings}
+	for _, genre := range movie.Genre {
+		req.Genres = append(req.Genres, genre.GenreName)
 	}
-	llm, err := openai.New(
-		openai.WithToken(openApiKey),
-	)
-	if err != nil {
-		return "", 0, err
+	if prompt != nil {
+		req.Template, req.PromptVersion = prompt.Template, prompt.Version
 	}
+	return req
+}
 
-	basePromptTemplate := os.Getenv("BASE_PROMPT_TEMPLATE")
-	basePrompt := strings.Replace(basePromptTemplate, "{rankings}", sentimentDelimited, 1)
-	response, err := llm.Call(context.Background(), basePrompt+adminReview)
-	if err != nil {
-		return "", 0, err
+func newReviewClassification(classification llm.Classification) models.ReviewClassification {
+	return models.ReviewClassification{
+		PromptVersion: classification.PromptVersion,
+		Model:         classification.Model,
+		ClassifiedAt:  time.Now(),
 	}
-	rankVal := 0
-	for _, ranking := range rankings {
-		if ranking.RankingName == response {
-			rankVal = ranking.RankingValue
-			break
-		}
-	}
-	return response, rankVal, nil
 }
 
+// GetRankings returns the ranking scale, served from memory while it is
+// cached.
 func GetRankings() ([]models.Ranking, error) {
+	if rankings, ok := cachedRankings(); ok {
+		return rankings, nil
+	}
 	var rankings []models.Ranking
 
 	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
@@ -215,6 +315,7 @@ func GetRankings() ([]models.Ranking, error) {
 	if err != nil {
 		return nil, err
 	}
+	rankingsCache.Set(rankingsCacheKey, slices.Clone(rankings))
 	return rankings, nil
 }
 
@@ -222,12 +323,12 @@ func GetRecomendedMovies() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID, err := utils.GetUserIDFromContext(c)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "userID is not found in context"})
+			respondError(c, errBadRequest("userID is not found in context"))
 			return
 		}
 		favouriteGenres, err := GetUsersFavouriteGeners(userID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			respondError(c, errInternal("Internal server error", err))
 			return
 		}
 
@@ -242,19 +343,28 @@ func GetRecomendedMovies() gin.HandlerFunc {
 		findOptions := options.Find()
 		findOptions.SetSort(bson.D{{Key: "ranking.ranking_value", Value: 1}})
 		findOptions.SetLimit(recommendedMovieLimitVal)
-		filter := bson.M{"genre.genre_name": bson.M{"$in": favouriteGenres}}
 
 		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
+		finished, err := finishedMovieIDs(ctx, userID)
+		if err != nil {
+			respondError(c, errInternal("Error fetching watch history", err))
+			return
+		}
+		filter := bson.M{
+			"genre.genre_name": bson.M{"$in": favouriteGenres},
+			"imdb_id":          bson.M{"$nin": finished},
+		}
+
 		cursor, err := movieCollection.Find(ctx, filter, findOptions)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching recommended movies"})
+			respondError(c, errInternal("Error fetching recommended movies", err))
 			return
 		}
 		var recommendedMovies []models.Movie
 		if err := cursor.All(ctx, &recommendedMovies); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			respondError(c, errInternal("Internal server error", err))
 			return
 		}
 		c.JSON(http.StatusOK, recommendedMovies)
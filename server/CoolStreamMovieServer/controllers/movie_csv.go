@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+)
+
+// movieCSVHeader is the column layout shared by CSV import and export.
+// Genres are flattened into one column as "genre_id:genre_name" pairs joined
+// by "|", and the ranking into its value and name columns.
+var movieCSVHeader = []string{
+	"imdb_id",
+	"title",
+	"poster_path",
+	"youtube_id",
+	"genres",
+	"admin_review",
+	"ranking_value",
+	"ranking_name",
+}
+
+const (
+	csvGenreSeparator     = "|"
+	csvGenreIDSeparator   = ":"
+	csvRequiredColumnsMsg = "CSV header must contain the columns: "
+)
+
+// csvColumns maps every movieCSVHeader column to its index in header.
+func csvColumns(header []string) (map[string]int, error) {
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.ToLower(name))] = i
+	}
+	for _, name := range movieCSVHeader {
+		if _, ok := columns[name]; !ok {
+			return nil, errors.New(csvRequiredColumnsMsg + strings.Join(movieCSVHeader, ","))
+		}
+	}
+	return columns, nil
+}
+
+func movieFromCSVRecord(columns map[string]int, record []string) (models.Movie, error) {
+	field := func(name string) string {
+		return strings.TrimSpace(record[columns[name]])
+	}
+	movie := models.Movie{
+		ImdbID:      field("imdb_id"),
+		Title:       field("title"),
+		PosterPath:  field("poster_path"),
+		YoutubeID:   field("youtube_id"),
+		AdminReview: field("admin_review"),
+		Ranking:     models.Ranking{RankingName: field("ranking_name")},
+	}
+	if value := field("ranking_value"); value != "" {
+		rankingValue, err := strconv.Atoi(value)
+		if err != nil {
+			return movie, fmt.Errorf("ranking_value %q is not an integer", value)
+		}
+		movie.Ranking.RankingValue = rankingValue
+	}
+	genres, err := parseCSVGenres(field("genres"))
+	if err != nil {
+		return movie, err
+	}
+	movie.Genre = genres
+	return movie, nil
+}
+
+func movieToCSVRecord(movie models.Movie) []string {
+	genres := make([]string, 0, len(movie.Genre))
+	for _, genre := range movie.Genre {
+		genres = append(genres, strconv.Itoa(genre.GenreID)+csvGenreIDSeparator+genre.GenreName)
+	}
+	return []string{
+		movie.ImdbID,
+		movie.Title,
+		movie.PosterPath,
+		movie.YoutubeID,
+		strings.Join(genres, csvGenreSeparator),
+		movie.AdminReview,
+		strconv.Itoa(movie.Ranking.RankingValue),
+		movie.Ranking.RankingName,
+	}
+}
+
+func parseCSVGenres(value string) ([]models.Genre, error) {
+	genres := []models.Genre{}
+	if value == "" {
+		return genres, nil
+	}
+	for pair := range strings.SplitSeq(value, csvGenreSeparator) {
+		idStr, name, found := strings.Cut(pair, csvGenreIDSeparator)
+		if !found {
+			return nil, fmt.Errorf("genre %q must be formatted as genre_id:genre_name", pair)
+		}
+		genreID, err := strconv.Atoi(strings.TrimSpace(idStr))
+		if err != nil {
+			return nil, fmt.Errorf("genre id %q is not an integer", idStr)
+		}
+		genres = append(genres, models.Genre{GenreID: genreID, GenreName: strings.TrimSpace(name)})
+	}
+	return genres, nil
+}
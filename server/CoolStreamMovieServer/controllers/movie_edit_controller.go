@@ -0,0 +1,280 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/textutil"
+)
+
+// ReplaceMovie answers PUT /movie/:imdb_id with a full replacement. The
+// caller must send the version it last read, in If-Match or the body.
+func ReplaceMovie() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		movieID := c.Param("imdb_id")
+
+		var movie models.Movie
+		if err := c.ShouldBindJSON(&movie); err != nil {
+			respondError(c, errBadRequest("Invalid input"))
+			return
+		}
+		if movie.ImdbID == "" {
+			movie.ImdbID = movieID
+		}
+		if movie.ImdbID != movieID {
+			respondError(c, errBadRequest("imdb_id cannot be changed"))
+			return
+		}
+		if err := validate.Struct(movie); err != nil {
+			respondError(c, errValidation(err))
+			return
+		}
+		version, ok := expectedMovieVersion(c, movie.Version)
+		if !ok {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		replaceMovieVersion(ctx, c, movie, version)
+	}
+}
+
+// PatchMovie answers PATCH /movie/:imdb_id with an RFC 7386 JSON merge
+// patch. The patched document must still pass the models.Movie validation.
+func PatchMovie() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		movieID := c.Param("imdb_id")
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			respondError(c, errBadRequest("Invalid input"))
+			return
+		}
+		var patch map[string]any
+		if err := json.Unmarshal(body, &patch); err != nil || patch == nil {
+			respondError(c, errBadRequest("Merge patch must be a JSON object"))
+			return
+		}
+		if _, ok := patch["_id"]; ok {
+			respondError(c, errBadRequest("_id cannot be changed"))
+			return
+		}
+		if imdbID, ok := patch["imdb_id"]; ok && imdbID != movieID {
+			respondError(c, errBadRequest("imdb_id cannot be changed"))
+			return
+		}
+		var bodyVersion int64
+		if value, ok := patch["version"].(float64); ok {
+			bodyVersion = int64(value)
+		}
+		delete(patch, "version")
+		version, ok := expectedMovieVersion(c, bodyVersion)
+		if !ok {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var current models.Movie
+		err = movieCollection.FindOne(ctx, bson.M{"imdb_id": movieID}).Decode(&current)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				respondError(c, errNotFound("Movie not found"))
+				return
+			}
+			respondError(c, errInternal("Error fetching movie", err))
+			return
+		}
+		if current.Version != version {
+			respondError(c, errConflict("Movie was modified by someone else").withDetails(gin.H{"version": current.Version}))
+			return
+		}
+
+		var document any
+		currentJSON, _ := json.Marshal(current)
+		if err := json.Unmarshal(currentJSON, &document); err != nil {
+			respondError(c, errInternal("Error applying patch", err))
+			return
+		}
+		patchedJSON, err := json.Marshal(mergePatch(document, patch))
+		if err != nil {
+			respondError(c, errInternal("Error applying patch", err))
+			return
+		}
+		var movie models.Movie
+		if err := json.Unmarshal(patchedJSON, &movie); err != nil {
+			respondError(c, errValidation(err))
+			return
+		}
+		if err := validate.Struct(movie); err != nil {
+			respondError(c, errValidation(err))
+			return
+		}
+
+		replaceMovieVersion(ctx, c, movie, version)
+	}
+}
+
+func DeleteMovie() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		movieID := c.Param("imdb_id")
+
+		filter := bson.M{"imdb_id": movieID}
+		if c.GetHeader("If-Match") != "" {
+			version, ok := expectedMovieVersion(c, 0)
+			if !ok {
+				return
+			}
+			filter["version"] = version
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		result, err := movieCollection.DeleteOne(ctx, filter)
+		if err != nil {
+			respondError(c, errInternal("Error deleting movie", err))
+			return
+		}
+		if result.DeletedCount == 0 {
+			respondMovieWriteMiss(ctx, c, movieID)
+			return
+		}
+		if _, err := reviewCollection.DeleteMany(ctx, bson.M{"imdb_id": movieID}); err != nil {
+			log.Error().Err(err).Str("imdbID", movieID).Msg("failed to delete reviews of deleted movie")
+		}
+		if _, err := watchlistCollection.DeleteMany(ctx, bson.M{"imdb_id": movieID}); err != nil {
+			log.Error().Err(err).Str("imdbID", movieID).Msg("failed to delete watchlist entries of deleted movie")
+		}
+		if _, err := watchProgressCollection.DeleteMany(ctx, bson.M{"imdb_id": movieID}); err != nil {
+			log.Error().Err(err).Str("imdbID", movieID).Msg("failed to delete watch progress of deleted movie")
+		}
+		if _, err := watchEventCollection.DeleteMany(ctx, bson.M{"meta.imdb_id": movieID}); err != nil {
+			log.Error().Err(err).Str("imdbID", movieID).Msg("failed to delete watch events of deleted movie")
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// replaceMovieVersion stores the editable fields of movie over the document
+// at version and bumps the version. Fields the server maintains, such as the
+// user ratings, are kept. A new admin review comes with the ranking the
+// caller chose, so the movie is detached from any queued classification of
+// the old one. It writes 404 or 409 when there is nothing to replace.
+func replaceMovieVersion(ctx context.Context, c *gin.Context, movie models.Movie, version int64) {
+	if err := validateGenres(ctx, movie.Genre); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var current models.Movie
+	filter := bson.M{"imdb_id": movie.ImdbID, "version": version}
+	err := movieCollection.FindOne(ctx, filter, options.FindOne().SetProjection(bson.M{"admin_review": 1})).Decode(&current)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			respondMovieWriteMiss(ctx, c, movie.ImdbID)
+			return
+		}
+		respondError(c, errInternal("Error fetching movie", err))
+		return
+	}
+
+	var replaced models.Movie
+	update := bson.M{
+		"$set": bson.M{
+			"title":        movie.Title,
+			"poster_path":  movie.PosterPath,
+			"youtube_id":   movie.YoutubeID,
+			"genre":        movie.Genre,
+			"admin_review": movie.AdminReview,
+			"ranking":      movie.Ranking,
+			"title_ngrams": textutil.Trigrams(movie.Title),
+			"version":      version + 1,
+		},
+	}
+	if movie.AdminReview != current.AdminReview {
+		update["$unset"] = bson.M{"review_job_id": "", "ranking_status": ""}
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = movieCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&replaced)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			respondMovieWriteMiss(ctx, c, movie.ImdbID)
+			return
+		}
+		respondError(c, errInternal("Error updating movie", err))
+		return
+	}
+	c.Header("ETag", movieETag(replaced.Version))
+	c.JSON(http.StatusOK, replaced)
+}
+
+// respondMovieWriteMiss tells apart a missing movie from a stale version
+// after a versioned write matched nothing.
+func respondMovieWriteMiss(ctx context.Context, c *gin.Context, movieID string) {
+	var current models.Movie
+	opts := options.FindOne().SetProjection(bson.M{"version": 1})
+	err := movieCollection.FindOne(ctx, bson.M{"imdb_id": movieID}, opts).Decode(&current)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			respondError(c, errNotFound("Movie not found"))
+			return
+		}
+		respondError(c, errInternal("Error fetching movie", err))
+		return
+	}
+	respondError(c, errConflict("Movie was modified by someone else").withDetails(gin.H{"version": current.Version}))
+}
+
+// expectedMovieVersion returns the version the caller based its edit on,
+// taken from If-Match or else from the body. It writes the error response
+// itself when neither carries a usable version.
+func expectedMovieVersion(c *gin.Context, bodyVersion int64) (int64, bool) {
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" {
+		value := strings.Trim(strings.TrimPrefix(ifMatch, "W/"), `"`)
+		version, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || version < 1 {
+			respondError(c, errBadRequest("If-Match must hold a movie version"))
+			return 0, false
+		}
+		return version, true
+	}
+	if bodyVersion < 1 {
+		respondError(c, errPreconditionRequired("version is required, send it in If-Match or the body"))
+		return 0, false
+	}
+	return bodyVersion, true
+}
+
+func movieETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// BackfillMovieVersions starts every movie written before versioning at
+// version 1, so that clients always have a version to send back.
+func BackfillMovieVersions(ctx context.Context) error {
+	filter := bson.M{"version": bson.M{"$exists": false}}
+	result, err := movieCollection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"version": 1}})
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount > 0 {
+		log.Info().Int64("count", result.ModifiedCount).Msg("backfilled movie versions")
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+)
+
+const (
+	exportFormatJSON   = "json"
+	exportFormatNDJSON = "ndjson"
+	exportFormatCSV    = "csv"
+
+	exportBatchSize = 500
+	exportTimeout   = 30 * time.Minute
+)
+
+var exportContentTypes = map[string]string{
+	exportFormatJSON:   "application/json",
+	exportFormatNDJSON: "application/x-ndjson",
+	exportFormatCSV:    "text/csv",
+}
+
+// ExportMovies answers GET /movies/export?format=json|ndjson|csv. It accepts
+// the same filter and sort parameters as GetMovies and streams documents from
+// the cursor straight to the response, so memory use does not grow with the
+// catalog. The CSV layout is the one ImportMovies reads.
+func ExportMovies() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format := c.DefaultQuery("format", exportFormatJSON)
+		contentType, ok := exportContentTypes[format]
+		if !ok {
+			respondError(c, errBadRequest("format must be json, ndjson or csv"))
+			return
+		}
+		query, err := parseMovieQuery(c)
+		if err != nil {
+			respondError(c, errBadRequest(err.Error()))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), exportTimeout)
+		defer cancel()
+
+		findOptions := options.Find().SetSort(query.sort(false)).SetBatchSize(exportBatchSize)
+		cursor, err := movieCollection.Find(ctx, query.filter, findOptions)
+		if err != nil {
+			respondError(c, errInternal("Failed to export movies", err))
+			return
+		}
+		defer cursor.Close(ctx)
+
+		filename := "movies-" + time.Now().UTC().Format("20060102-150405") + "." + format
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+		c.Status(http.StatusOK)
+
+		// Headers are sent by now, so a failure can only cut the stream short.
+		if err := writeMovieExport(c, cursor, format); err != nil {
+			log.Error().Err(err).Str("format", format).Msg("movie export aborted")
+		}
+	}
+}
+
+func writeMovieExport(c *gin.Context, cursor *mongo.Cursor, format string) error {
+	ctx := c.Request.Context()
+	w := c.Writer
+	csvWriter := csv.NewWriter(w)
+
+	switch format {
+	case exportFormatJSON:
+		if _, err := w.WriteString("["); err != nil {
+			return err
+		}
+	case exportFormatCSV:
+		if err := csvWriter.Write(movieCSVHeader); err != nil {
+			return err
+		}
+	}
+
+	written := 0
+	for cursor.Next(ctx) {
+		var movie models.Movie
+		if err := cursor.Decode(&movie); err != nil {
+			return err
+		}
+		switch format {
+		case exportFormatCSV:
+			if err := csvWriter.Write(movieToCSVRecord(movie)); err != nil {
+				return err
+			}
+		default:
+			data, err := json.Marshal(movie)
+			if err != nil {
+				return err
+			}
+			if format == exportFormatJSON && written > 0 {
+				data = append([]byte(","), data...)
+			}
+			if format == exportFormatNDJSON {
+				data = append(data, '\n')
+			}
+			if _, err := w.Write(data); err != nil {
+				return err
+			}
+		}
+		written++
+		if written%exportBatchSize == 0 {
+			csvWriter.Flush()
+			w.Flush()
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	switch format {
+	case exportFormatJSON:
+		if _, err := w.WriteString("]"); err != nil {
+			return err
+		}
+	case exportFormatCSV:
+		csvWriter.Flush()
+		if err := csvWriter.Error(); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return nil
+}
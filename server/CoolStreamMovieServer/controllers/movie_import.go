@@ -0,0 +1,336 @@
+package controllers
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/textutil"
+)
+
+const (
+	ImportFormatCSV    = "csv"
+	ImportFormatNDJSON = "ndjson"
+
+	importBatchSize = 500
+	// maxReportedImportErrors bounds the report size when a large file is
+	// mostly invalid; Failed still counts every bad row.
+	maxReportedImportErrors = 1000
+	importTimeout           = 30 * time.Minute
+)
+
+// importRecord is one parsed row; err is set when the row could not be
+// turned into a movie at all.
+type importRecord struct {
+	row   int
+	movie models.Movie
+	err   error
+}
+
+type importReader interface {
+	// next returns io.EOF once the input is exhausted. Any other error
+	// aborts the import; row level problems are reported in the record.
+	next() (importRecord, error)
+}
+
+// csvImportReader reports rows by their starting line in the file, which
+// differs from the record count when quoted fields span lines.
+type csvImportReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+func newCSVImportReader(r io.Reader) (*csvImportReader, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading CSV header: %w", err)
+	}
+	columns, err := csvColumns(header)
+	if err != nil {
+		return nil, err
+	}
+	reader.FieldsPerRecord = len(header)
+	return &csvImportReader{reader: reader, columns: columns}, nil
+}
+
+func (r *csvImportReader) next() (importRecord, error) {
+	record, err := r.reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return importRecord{row: parseErr.StartLine, err: parseErr.Err}, nil
+		}
+		return importRecord{}, err
+	}
+	row, _ := r.reader.FieldPos(0)
+	movie, err := movieFromCSVRecord(r.columns, record)
+	return importRecord{row: row, movie: movie, err: err}, nil
+}
+
+type ndjsonImportReader struct {
+	reader *bufio.Reader
+	row    int
+}
+
+func (r *ndjsonImportReader) next() (importRecord, error) {
+	for {
+		line, err := r.reader.ReadBytes('\n')
+		if err != nil && !(errors.Is(err, io.EOF) && len(line) > 0) {
+			return importRecord{}, err
+		}
+		r.row++
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		var movie models.Movie
+		if err := json.Unmarshal(line, &movie); err != nil {
+			return importRecord{row: r.row, err: fmt.Errorf("invalid JSON: %w", err)}, nil
+		}
+		return importRecord{row: r.row, movie: movie}, nil
+	}
+}
+
+// ImportMovies streams movies in the given format from r, validates each
+// one with the models.Movie rules and upserts the valid ones by imdb_id in
+// batches. With dryRun nothing is written and the report tells what would
+// have been inserted or updated.
+func ImportMovies(ctx context.Context, r io.Reader, format string, dryRun bool) (*models.ImportReport, error) {
+	var reader importReader
+	switch format {
+	case ImportFormatCSV:
+		csvReader, err := newCSVImportReader(r)
+		if err != nil {
+			return nil, err
+		}
+		reader = csvReader
+	case ImportFormatNDJSON:
+		reader = &ndjsonImportReader{reader: bufio.NewReaderSize(r, 64*1024)}
+	default:
+		return nil, fmt.Errorf("unsupported import format %q", format)
+	}
+
+	// The genre list is small, so it is loaded once instead of per row.
+	genres, err := loadGenres(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ImportReport{DryRun: dryRun, Errors: []models.ImportRowError{}}
+	batch := make([]importRecord, 0, importBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		var err error
+		if dryRun {
+			err = dryRunImportBatch(ctx, batch, report)
+		} else {
+			err = writeImportBatch(ctx, batch, report)
+		}
+		batch = batch[:0]
+		return err
+	}
+
+	for {
+		record, err := reader.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return report, err
+		}
+		report.Processed++
+		if record.err == nil {
+			record.err = validateImportedMovie(record.movie, genres)
+		}
+		if record.err != nil {
+			addImportError(report, record.row, record.movie.ImdbID, record.err)
+			continue
+		}
+		batch = append(batch, record)
+		if len(batch) == importBatchSize {
+			if err := flush(); err != nil {
+				return report, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+func validateImportedMovie(movie models.Movie, genres map[int]string) error {
+	if err := validate.Struct(movie); err != nil {
+		return err
+	}
+	return checkGenres(genres, movie.Genre)
+}
+
+func writeImportBatch(ctx context.Context, batch []importRecord, report *models.ImportReport) error {
+	writes := make([]mongo.WriteModel, 0, len(batch))
+	for _, record := range batch {
+		movie := record.movie
+		update := bson.M{
+			"$set": bson.M{
+				"title":        movie.Title,
+				"poster_path":  movie.PosterPath,
+				"youtube_id":   movie.YoutubeID,
+				"genre":        movie.Genre,
+				"admin_review": movie.AdminReview,
+				"ranking":      movie.Ranking,
+				"title_ngrams": textutil.Trigrams(movie.Title),
+			},
+			"$inc": bson.M{"version": 1},
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"imdb_id": movie.ImdbID}).
+			SetUpdate(update).
+			SetUpsert(true))
+	}
+
+	result, err := movieCollection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if result != nil {
+		report.Inserted += int(result.UpsertedCount)
+		report.Updated += int(result.MatchedCount)
+	}
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) && len(bulkErr.WriteErrors) > 0 {
+		for _, writeErr := range bulkErr.WriteErrors {
+			record := batch[writeErr.Index]
+			addImportError(report, record.row, record.movie.ImdbID, errors.New(writeErr.Message))
+		}
+		return nil
+	}
+	return err
+}
+
+func dryRunImportBatch(ctx context.Context, batch []importRecord, report *models.ImportReport) error {
+	imdbIDs := make([]string, 0, len(batch))
+	for _, record := range batch {
+		imdbIDs = append(imdbIDs, record.movie.ImdbID)
+	}
+	opts := options.Find().SetProjection(bson.M{"imdb_id": 1, "_id": 0})
+	cursor, err := movieCollection.Find(ctx, bson.M{"imdb_id": bson.M{"$in": imdbIDs}}, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	existing := map[string]bool{}
+	for cursor.Next(ctx) {
+		var movie models.Movie
+		if err := cursor.Decode(&movie); err != nil {
+			return err
+		}
+		existing[movie.ImdbID] = true
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	for _, record := range batch {
+		if existing[record.movie.ImdbID] {
+			report.Updated++
+		} else {
+			// A later row for the same imdb_id would update this one.
+			existing[record.movie.ImdbID] = true
+			report.Inserted++
+		}
+	}
+	return nil
+}
+
+func addImportError(report *models.ImportReport, row int, imdbID string, err error) {
+	report.Failed++
+	if len(report.Errors) >= maxReportedImportErrors {
+		report.ErrorsTruncated = true
+		return
+	}
+	report.Errors = append(report.Errors, models.ImportRowError{Row: row, ImdbID: imdbID, Error: err.Error()})
+}
+
+// ImportFormatFromName infers the import format from a file name or content
+// type, returning "" when neither is recognised.
+func ImportFormatFromName(name string) string {
+	if mediaType, _, err := mime.ParseMediaType(name); err == nil {
+		switch mediaType {
+		case "text/csv":
+			return ImportFormatCSV
+		case "application/x-ndjson", "application/jsonl", "application/json-seq":
+			return ImportFormatNDJSON
+		}
+	}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return ImportFormatCSV
+	case ".ndjson", ".jsonl":
+		return ImportFormatNDJSON
+	}
+	return ""
+}
+
+// ImportMoviesHandler answers POST /movies/import. The file is either the
+// raw request body or the "file" field of a multipart form; its format comes
+// from ?format= or else from the content type or file name.
+func ImportMoviesHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format := c.Query("format")
+		dryRun := c.Query("dry_run") == "true"
+
+		body := io.Reader(c.Request.Body)
+		if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+			fileHeader, err := c.FormFile("file")
+			if err != nil {
+				respondError(c, errBadRequest("file is required"))
+				return
+			}
+			file, err := fileHeader.Open()
+			if err != nil {
+				respondError(c, errBadRequest("Unable to read file"))
+				return
+			}
+			defer file.Close()
+			body = file
+			if format == "" {
+				format = ImportFormatFromName(fileHeader.Filename)
+			}
+		} else if format == "" {
+			format = ImportFormatFromName(c.ContentType())
+		}
+		if format != ImportFormatCSV && format != ImportFormatNDJSON {
+			respondError(c, errBadRequest("format must be csv or ndjson"))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), importTimeout)
+		defer cancel()
+
+		report, err := ImportMovies(ctx, body, format, dryRun)
+		if err != nil {
+			if report == nil {
+				respondError(c, errBadRequest(err.Error()))
+				return
+			}
+			respondError(c, errInternal("Import aborted", err).withDetails(report))
+			return
+		}
+		c.JSON(http.StatusOK, report)
+	}
+}
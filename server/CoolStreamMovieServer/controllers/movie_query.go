@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+)
+
+// movieSortFields maps the public sort names to document fields. Every sort
+// is made total by _id, which is also the insertion ("date added") order.
+var movieSortFields = map[string]string{
+	"title":      "title",
+	"ranking":    "ranking.ranking_value",
+	"date_added": "_id",
+}
+
+type movieQuery struct {
+	filter    bson.M
+	sortName  string
+	sortField string
+	sortOrder int
+}
+
+// movieCursor is the decoded form of the opaque cursor query parameter. It
+// records the sort key and _id of the item at the page boundary.
+type movieCursor struct {
+	Sort      string        `json:"s"`
+	Order     int           `json:"o"`
+	Direction string        `json:"d"`
+	Key       any           `json:"k,omitempty"`
+	ID        bson.ObjectID `json:"id"`
+}
+
+const (
+	cursorNext = "next"
+	cursorPrev = "prev"
+)
+
+// parseMovieQuery builds the listing filter and sort from the query string:
+// genre (repeated or comma separated), genre_match=any|all, min_ranking,
+// max_ranking, title_prefix, sort=title|ranking|date_added and order=asc|desc.
+func parseMovieQuery(c *gin.Context) (movieQuery, error) {
+	query := movieQuery{filter: bson.M{}}
+
+	var genres []string
+	for _, value := range c.QueryArray("genre") {
+		for genre := range strings.SplitSeq(value, ",") {
+			if genre = strings.TrimSpace(genre); genre != "" {
+				genres = append(genres, genre)
+			}
+		}
+	}
+	if len(genres) > 0 {
+		switch c.DefaultQuery("genre_match", "any") {
+		case "any":
+			query.filter["genre.genre_name"] = bson.M{"$in": genres}
+		case "all":
+			query.filter["genre.genre_name"] = bson.M{"$all": genres}
+		default:
+			return query, errors.New("genre_match must be any or all")
+		}
+	}
+
+	rankingRange := bson.M{}
+	if minStr := c.Query("min_ranking"); minStr != "" {
+		val, err := strconv.Atoi(minStr)
+		if err != nil {
+			return query, errors.New("min_ranking must be an integer")
+		}
+		rankingRange["$gte"] = val
+	}
+	if maxStr := c.Query("max_ranking"); maxStr != "" {
+		val, err := strconv.Atoi(maxStr)
+		if err != nil {
+			return query, errors.New("max_ranking must be an integer")
+		}
+		rankingRange["$lte"] = val
+	}
+	if len(rankingRange) > 0 {
+		query.filter["ranking.ranking_value"] = rankingRange
+	}
+
+	if prefix := c.Query("title_prefix"); prefix != "" {
+		query.filter["title"] = bson.Regex{Pattern: "^" + regexp.QuoteMeta(prefix), Options: "i"}
+	}
+
+	query.sortName = c.DefaultQuery("sort", "date_added")
+	sortField, ok := movieSortFields[query.sortName]
+	if !ok {
+		return query, errors.New("sort must be one of title, ranking or date_added")
+	}
+	query.sortField = sortField
+
+	defaultOrder := "asc"
+	if query.sortName == "date_added" {
+		defaultOrder = "desc"
+	}
+	switch c.DefaultQuery("order", defaultOrder) {
+	case "asc":
+		query.sortOrder = 1
+	case "desc":
+		query.sortOrder = -1
+	default:
+		return query, errors.New("order must be asc or desc")
+	}
+	return query, nil
+}
+
+// sort returns the sort document, reversed when walking backwards from a
+// prev cursor.
+func (q movieQuery) sort(reverse bool) bson.D {
+	order := q.sortOrder
+	if reverse {
+		order = -order
+	}
+	if q.sortField == "_id" {
+		return bson.D{{Key: "_id", Value: order}}
+	}
+	return bson.D{{Key: q.sortField, Value: order}, {Key: "_id", Value: order}}
+}
+
+// keysetFilter restricts filter to the documents after (or, for a prev
+// cursor, before) the cursor position in the query's sort order.
+func (q movieQuery) keysetFilter(cursor movieCursor) bson.M {
+	op := "$gt"
+	if (q.sortOrder == -1) != (cursor.Direction == cursorPrev) {
+		op = "$lt"
+	}
+	var after bson.M
+	if q.sortField == "_id" {
+		after = bson.M{"_id": bson.M{op: cursor.ID}}
+	} else {
+		after = bson.M{"$or": bson.A{
+			bson.M{q.sortField: bson.M{op: cursor.Key}},
+			bson.M{q.sortField: cursor.Key, "_id": bson.M{op: cursor.ID}},
+		}}
+	}
+	if len(q.filter) == 0 {
+		return after
+	}
+	return bson.M{"$and": bson.A{q.filter, after}}
+}
+
+func (q movieQuery) encodeCursor(direction string, key any, id bson.ObjectID) string {
+	cursor := movieCursor{Sort: q.sortName, Order: q.sortOrder, Direction: direction, ID: id}
+	if q.sortField != "_id" {
+		cursor.Key = key
+	}
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func (q movieQuery) decodeCursor(value string) (movieCursor, error) {
+	var cursor movieCursor
+	errInvalid := errors.New("invalid cursor")
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, errInvalid
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return cursor, errInvalid
+	}
+	if cursor.Direction != cursorNext && cursor.Direction != cursorPrev {
+		return cursor, errInvalid
+	}
+	if cursor.Sort != q.sortName || cursor.Order != q.sortOrder {
+		return cursor, errors.New("cursor does not match the requested sort")
+	}
+	if number, ok := cursor.Key.(float64); ok {
+		cursor.Key = int(number)
+	}
+	return cursor, nil
+}
+
+func movieSortKey(movie models.Movie, q movieQuery) any {
+	switch q.sortField {
+	case "title":
+		return movie.Title
+	case "ranking.ranking_value":
+		return movie.Ranking.RankingValue
+	}
+	return nil
+}
+
+// pageLink returns the request URL with the given query parameters replaced
+// and the pagination parameters that do not apply removed.
+func pageLink(c *gin.Context, set map[string]string, drop ...string) string {
+	values := url.Values{}
+	for key, vals := range c.Request.URL.Query() {
+		values[key] = vals
+	}
+	for _, key := range drop {
+		values.Del(key)
+	}
+	for key, val := range set {
+		values.Set(key, val)
+	}
+	return c.Request.URL.Path + "?" + values.Encode()
+}
@@ -0,0 +1,218 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/textutil"
+)
+
+const (
+	maxSearchQueryLength = 200
+	// fuzzyCandidateLimit caps how many trigram candidates are scored in
+	// process when the text index finds nothing.
+	fuzzyCandidateLimit = 200
+	minFuzzyScore       = 0.25
+)
+
+type scoredMovie struct {
+	models.Movie `bson:",inline"`
+	Score        float64 `bson:"score"`
+}
+
+// SearchMovies answers GET /movies/search?q=. It ranks matches from the text
+// index by relevance and, when the index finds nothing, falls back to trigram
+// candidates re-scored by edit distance so that misspelt titles still match.
+func SearchMovies() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		q := strings.TrimSpace(c.Query("q"))
+		if q == "" {
+			respondError(c, errBadRequest("q is required"))
+			return
+		}
+		if len(q) > maxSearchQueryLength {
+			respondError(c, errBadRequest("q is too long"))
+			return
+		}
+		page, limit, err := parsePagination(c)
+		if err != nil {
+			respondError(c, errBadRequest(err.Error()))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		mode := "text"
+		movies, total, err := textSearchMovies(ctx, q, page, limit)
+		if err == nil && total == 0 {
+			mode = "fuzzy"
+			movies, total, err = fuzzySearchMovies(ctx, q, page, limit)
+		}
+		if err != nil {
+			respondError(c, errInternal("Failed to search movies", err))
+			return
+		}
+
+		var links models.PageLinks
+		if page*limit < total {
+			links.Next = pageLink(c, map[string]string{"page": strconv.FormatInt(page+1, 10)})
+		}
+		if page > 1 {
+			links.Prev = pageLink(c, map[string]string{"page": strconv.FormatInt(page-1, 10)})
+		}
+		response := gin.H{
+			"movies": movies,
+			"mode":   mode,
+			"meta":   newPageMeta(page, limit, total),
+			"links":  links,
+		}
+		if c.Query("highlight") == "true" {
+			response["highlights"] = highlightMovies(movies, q)
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func textSearchMovies(ctx context.Context, q string, page, limit int64) ([]models.Movie, int64, error) {
+	filter := bson.M{"$text": bson.M{"$search": q}}
+	total, err := movieCollection.CountDocuments(ctx, filter)
+	if err != nil || total == 0 {
+		return []models.Movie{}, total, err
+	}
+
+	findOptions := options.Find().
+		SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "_id", Value: 1}}).
+		SetSkip((page - 1) * limit).
+		SetLimit(limit)
+	cursor, err := movieCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var scored []scoredMovie
+	if err := cursor.All(ctx, &scored); err != nil {
+		return nil, 0, err
+	}
+	movies := make([]models.Movie, 0, len(scored))
+	for _, movie := range scored {
+		movies = append(movies, movie.Movie)
+	}
+	return movies, total, nil
+}
+
+func fuzzySearchMovies(ctx context.Context, q string, page, limit int64) ([]models.Movie, int64, error) {
+	grams := textutil.Trigrams(q)
+	if len(grams) == 0 {
+		return []models.Movie{}, 0, nil
+	}
+	// Let Mongo pick the titles sharing the most grams with the query, then
+	// score only those in process.
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"title_ngrams": bson.M{"$in": grams}}},
+		bson.M{"$addFields": bson.M{"score": bson.M{"$size": bson.M{"$setIntersection": bson.A{"$title_ngrams", grams}}}}},
+		bson.M{"$sort": bson.D{{Key: "score", Value: -1}, {Key: "_id", Value: 1}}},
+		bson.M{"$limit": fuzzyCandidateLimit},
+	}
+	cursor, err := movieCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var candidates []scoredMovie
+	if err := cursor.All(ctx, &candidates); err != nil {
+		return nil, 0, err
+	}
+
+	terms := textutil.Words(q)
+	matches := candidates[:0]
+	for _, candidate := range candidates {
+		titleWords := textutil.Words(candidate.Title)
+		matched := 0
+		for _, term := range terms {
+			if slices.ContainsFunc(titleWords, func(word string) bool { return textutil.FuzzyWordMatch(word, term) }) {
+				matched++
+			}
+		}
+		wordScore := float64(matched) / float64(len(terms))
+		candidate.Score = (textutil.TrigramSimilarity(q, candidate.Title) + wordScore) / 2
+		if matched > 0 && candidate.Score >= minFuzzyScore {
+			matches = append(matches, candidate)
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b scoredMovie) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return strings.Compare(a.Title, b.Title)
+	})
+
+	total := int64(len(matches))
+	start := min((page-1)*limit, total)
+	end := min(start+limit, total)
+	movies := make([]models.Movie, 0, end-start)
+	for _, match := range matches[start:end] {
+		movies = append(movies, match.Movie)
+	}
+	return movies, total, nil
+}
+
+// highlightMovies returns, per imdb_id, the title and admin review with the
+// words matching the query wrapped in <em> tags.
+func highlightMovies(movies []models.Movie, q string) map[string]gin.H {
+	terms := textutil.Words(q)
+	match := func(word string) bool {
+		return slices.ContainsFunc(terms, func(term string) bool { return textutil.FuzzyWordMatch(word, term) })
+	}
+	highlights := make(map[string]gin.H, len(movies))
+	for _, movie := range movies {
+		highlights[movie.ImdbID] = gin.H{
+			"title":        textutil.Highlight(movie.Title, match),
+			"admin_review": textutil.Highlight(movie.AdminReview, match),
+		}
+	}
+	return highlights
+}
+
+// BackfillSearchNgrams derives title_ngrams for movies written before search
+// existed. It only touches documents that lack the field.
+func BackfillSearchNgrams(ctx context.Context) error {
+	filter := bson.M{"title_ngrams": bson.M{"$exists": false}}
+	cursor, err := movieCollection.Find(ctx, filter, options.Find().SetProjection(bson.M{"title": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	updated := 0
+	for cursor.Next(ctx) {
+		var movie models.Movie
+		if err := cursor.Decode(&movie); err != nil {
+			return err
+		}
+		update := bson.M{"$set": bson.M{"title_ngrams": textutil.Trigrams(movie.Title)}}
+		if _, err := movieCollection.UpdateByID(ctx, movie.ID, update); err != nil {
+			return err
+		}
+		updated++
+	}
+	if updated > 0 {
+		log.Info().Int("count", updated).Msg("backfilled movie search ngrams")
+	}
+	return cursor.Err()
+}
@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+)
+
+const (
+	defaultPageLimit int64 = 20
+	maxPageLimit     int64 = 100
+)
+
+// parsePagination reads the page and limit query parameters, defaulting to
+// the first page and capping limit at maxPageLimit.
+func parsePagination(c *gin.Context) (int64, int64, error) {
+	page := int64(1)
+	limit := defaultPageLimit
+
+	if pageStr := c.Query("page"); pageStr != "" {
+		val, err := strconv.ParseInt(pageStr, 10, 64)
+		if err != nil || val < 1 {
+			return 0, 0, errors.New("page must be a positive integer")
+		}
+		page = val
+	}
+	if limitStr := c.Query("limit"); limitStr != "" {
+		val, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil || val < 1 {
+			return 0, 0, errors.New("limit must be a positive integer")
+		}
+		limit = min(val, maxPageLimit)
+	}
+	return page, limit, nil
+}
+
+func newPageMeta(page, limit, totalCount int64) models.PageMeta {
+	return models.PageMeta{
+		Page:       page,
+		Limit:      limit,
+		TotalCount: totalCount,
+		TotalPages: (totalCount + limit - 1) / limit,
+	}
+}
@@ -0,0 +1,203 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/utils"
+)
+
+// GetMe returns the caller's profile.
+func GetMe() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			respondError(c, errUnauthorized("Authentication required"))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var user models.User
+		if err := userCollection.FindOne(ctx, bson.M{"user_id": userID}).Decode(&user); err != nil {
+			respondUserLookupError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, toPublicUser(user))
+	}
+}
+
+// UpdateMe changes the caller's name. Fields left out keep their value; the
+// email, role and password cannot be changed here.
+func UpdateMe() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			respondError(c, errUnauthorized("Authentication required"))
+			return
+		}
+		var req struct {
+			FirstName *string `json:"first_name" validate:"omitnil,min=2,max=100"`
+			LastName  *string `json:"last_name"  validate:"omitnil,min=2,max=100"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, errBadRequest("Invalid request body"))
+			return
+		}
+		set := bson.M{}
+		if req.FirstName != nil {
+			*req.FirstName = strings.TrimSpace(*req.FirstName)
+			set["first_name"] = *req.FirstName
+		}
+		if req.LastName != nil {
+			*req.LastName = strings.TrimSpace(*req.LastName)
+			set["last_name"] = *req.LastName
+		}
+		if len(set) == 0 {
+			respondError(c, errBadRequest("Nothing to update"))
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			respondError(c, errValidation(err))
+			return
+		}
+		set["updated_at"] = time.Now()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		user, err := updateMe(ctx, userID, set)
+		if err != nil {
+			respondUserLookupError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, toPublicUser(user))
+	}
+}
+
+// ChangePassword replaces the caller's password after checking the current
+// one, and signs the caller out everywhere.
+func ChangePassword() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			respondError(c, errUnauthorized("Authentication required"))
+			return
+		}
+		var req struct {
+			CurrentPassword string `json:"current_password" validate:"required"`
+			NewPassword     string `json:"new_password"     validate:"required,min=6"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, errBadRequest("Invalid request body"))
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			respondError(c, errValidation(err))
+			return
+		}
+		if req.NewPassword == req.CurrentPassword {
+			respondError(c, errBadRequest("New password must differ from the current one"))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var user models.User
+		if err := userCollection.FindOne(ctx, bson.M{"user_id": userID}).Decode(&user); err != nil {
+			respondUserLookupError(c, err)
+			return
+		}
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.CurrentPassword)); err != nil {
+			respondError(c, errForbidden("Current password is incorrect"))
+			return
+		}
+		hashedPassword, err := HashPassword(req.NewPassword)
+		if err != nil {
+			respondError(c, errInternal("Failed to hash password", err))
+			return
+		}
+		// Matching the old hash keeps two concurrent changes from both
+		// passing the check above.
+		filter := bson.M{"user_id": userID, "password": user.Password}
+		update := bson.M{"$set": bson.M{"password": hashedPassword, "updated_at": time.Now()}}
+		result, err := userCollection.UpdateOne(ctx, filter, update)
+		if err != nil {
+			respondError(c, errInternal("Failed to change password", err))
+			return
+		}
+		if result.MatchedCount == 0 {
+			respondError(c, errConflict("Password was changed concurrently"))
+			return
+		}
+		if err := utils.RevokeAllTokens(userID); err != nil {
+			respondError(c, errInternal("Failed to revoke tokens", err))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Password changed, please log in again"})
+	}
+}
+
+// UpdateMyGenres replaces the caller's favourite genres, which drive their
+// recommendations.
+func UpdateMyGenres() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			respondError(c, errUnauthorized("Authentication required"))
+			return
+		}
+		var req struct {
+			FavoriteGenres []models.Genre `json:"favourite_genres" validate:"required,dive"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, errBadRequest("Invalid request body"))
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			respondError(c, errValidation(err))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		if err := validateGenres(ctx, req.FavoriteGenres); err != nil {
+			respondError(c, err)
+			return
+		}
+		user, err := updateMe(ctx, userID, bson.M{"favourite_genres": req.FavoriteGenres, "updated_at": time.Now()})
+		if err != nil {
+			respondUserLookupError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, toPublicUser(user))
+	}
+}
+
+func updateMe(ctx context.Context, userID string, set bson.M) (models.User, error) {
+	var user models.User
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := userCollection.FindOneAndUpdate(ctx, bson.M{"user_id": userID}, bson.M{"$set": set}, opts).Decode(&user)
+	return user, err
+}
+
+func respondUserLookupError(c *gin.Context, err error) {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		respondError(c, errNotFound("User not found"))
+		return
+	}
+	respondError(c, errInternal("Failed to fetch user", err))
+}
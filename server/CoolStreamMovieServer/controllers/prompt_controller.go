@@ -0,0 +1,376 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/database"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/llm"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/utils"
+)
+
+// maxPromptVersionAttempts bounds the retries when two admins save a prompt
+// at the same time and race for the next version number.
+const maxPromptVersionAttempts = 3
+
+var promptCollection *mongo.Collection = database.OpenCollection("prompt_templates")
+
+// activePromptTemplate returns the active prompt, or nil when none is active
+// and classifiers fall back to BASE_PROMPT_TEMPLATE.
+func activePromptTemplate(ctx context.Context) (*models.PromptTemplate, error) {
+	var prompt models.PromptTemplate
+	err := promptCollection.FindOne(ctx, bson.M{"active": true}).Decode(&prompt)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &prompt, nil
+}
+
+// ListPromptTemplates lists prompt versions newest first; ?active=true only
+// returns the active one.
+func ListPromptTemplates() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, limit, err := parsePagination(c)
+		if err != nil {
+			respondError(c, errBadRequest(err.Error()))
+			return
+		}
+		filter := bson.M{}
+		if c.Query("active") == "true" {
+			filter["active"] = true
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		total, err := promptCollection.CountDocuments(ctx, filter)
+		if err != nil {
+			respondError(c, errInternal("Failed to count prompt templates", err))
+			return
+		}
+		findOptions := options.Find().
+			SetSort(bson.D{{Key: "version", Value: -1}}).
+			SetSkip((page - 1) * limit).
+			SetLimit(limit)
+		cursor, err := promptCollection.Find(ctx, filter, findOptions)
+		if err != nil {
+			respondError(c, errInternal("Failed to fetch prompt templates", err))
+			return
+		}
+		defer cursor.Close(ctx)
+
+		prompts := []models.PromptTemplate{}
+		if err := cursor.All(ctx, &prompts); err != nil {
+			respondError(c, errInternal("Failed to decode prompt templates", err))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"prompts": prompts, "meta": newPageMeta(page, limit, total)})
+	}
+}
+
+func GetPromptTemplate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		version, ok := promptVersionParam(c)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		prompt, err := findPromptTemplate(ctx, version)
+		if err != nil {
+			respondPromptError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, prompt)
+	}
+}
+
+// CreatePromptTemplate saves a new prompt version, activating it when asked.
+// Existing rankings keep the version that produced them until the catalog is
+// re-ranked.
+func CreatePromptTemplate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Template string `json:"template"`
+			Note     string `json:"note"`
+			Activate bool   `json:"activate"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, errBadRequest("Invalid request body"))
+			return
+		}
+		if err := llm.ValidatePromptTemplate(req.Template); err != nil {
+			respondError(c, errValidation(err))
+			return
+		}
+		adminID, _ := utils.GetUserIDFromContext(c)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		prompt := models.PromptTemplate{
+			Template:  req.Template,
+			Note:      strings.TrimSpace(req.Note),
+			CreatedBy: adminID,
+			CreatedAt: time.Now(),
+		}
+		var err error
+		for range maxPromptVersionAttempts {
+			if prompt.Version, err = nextPromptVersion(ctx); err != nil {
+				break
+			}
+			if _, err = promptCollection.InsertOne(ctx, prompt); !mongo.IsDuplicateKeyError(err) {
+				break
+			}
+		}
+		if err != nil {
+			respondError(c, errInternal("Failed to save prompt template", err))
+			return
+		}
+		if req.Activate {
+			if err := activatePromptVersion(ctx, prompt.Version); err != nil {
+				respondError(c, errInternal("Failed to activate prompt template", err))
+				return
+			}
+			prompt.Active = true
+		}
+		c.JSON(http.StatusCreated, prompt)
+	}
+}
+
+func ActivatePromptTemplate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		version, ok := promptVersionParam(c)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		if _, err := findPromptTemplate(ctx, version); err != nil {
+			respondPromptError(c, err)
+			return
+		}
+		if err := activatePromptVersion(ctx, version); err != nil {
+			respondError(c, errInternal("Failed to activate prompt template", err))
+			return
+		}
+		prompt, err := findPromptTemplate(ctx, version)
+		if err != nil {
+			respondPromptError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, prompt)
+	}
+}
+
+// PreviewPromptTemplate renders a prompt for a review without saving
+// anything. The prompt is the given template text, the given version or the
+// active one, and the movie context comes from imdb_id or from title and
+// genres. With "classify": true the review is also classified with it.
+func PreviewPromptTemplate(classifier llm.ReviewClassifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Template string   `json:"template"`
+			Version  int      `json:"version"`
+			ImdbID   string   `json:"imdb_id"`
+			Title    string   `json:"title"`
+			Genres   []string `json:"genres"`
+			Review   string   `json:"review"`
+			Classify bool     `json:"classify"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, errBadRequest("Invalid request body"))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		prompt, err := previewPrompt(ctx, req.Template, req.Version)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		movie := models.Movie{Title: req.Title}
+		for _, name := range req.Genres {
+			movie.Genre = append(movie.Genre, models.Genre{GenreName: name})
+		}
+		if req.ImdbID != "" {
+			if err := movieCollection.FindOne(ctx, bson.M{"imdb_id": req.ImdbID}).Decode(&movie); err != nil {
+				if errors.Is(err, mongo.ErrNoDocuments) {
+					respondError(c, errNotFound("Movie not found"))
+					return
+				}
+				respondError(c, errInternal("Failed to fetch movie", err))
+				return
+			}
+		}
+		review := req.Review
+		if review == "" {
+			review = movie.AdminReview
+		}
+		if review == "" {
+			respondError(c, errBadRequest("review is required"))
+			return
+		}
+
+		rankings, err := GetRankings()
+		if err != nil {
+			respondError(c, errInternal("Failed to fetch rankings", err))
+			return
+		}
+		classifyReq := newClassifyRequest(movie, review, rankings, prompt)
+		var names []string
+		for _, ranking := range rankings {
+			if !ranking.Unranked {
+				names = append(names, ranking.RankingName)
+			}
+		}
+		resp := gin.H{
+			"prompt_version": prompt.Version,
+			"prompt": llm.BuildClassificationPrompt(prompt.Template, llm.PromptData{
+				Rankings: names,
+				Title:    classifyReq.Title,
+				Genres:   classifyReq.Genres,
+				Review:   review,
+			}),
+		}
+		if req.Classify {
+			classification, err := classifier.Classify(ctx, classifyReq)
+			if err != nil {
+				if errors.Is(err, llm.ErrUnparseableResponse) {
+					respondError(c, errBadGateway("Review classifier returned no valid ranking", err))
+					return
+				}
+				respondError(c, errInternal("Error getting review ranking", err))
+				return
+			}
+			resp["classification"] = gin.H{"ranking": classification.Ranking, "model": classification.Model}
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// previewPrompt resolves the prompt to preview; an unsaved template is
+// reported as version 0.
+func previewPrompt(ctx context.Context, template string, version int) (*models.PromptTemplate, error) {
+	if template != "" {
+		if err := llm.ValidatePromptTemplate(template); err != nil {
+			return nil, errValidation(err)
+		}
+		return &models.PromptTemplate{Template: template}, nil
+	}
+	if version != 0 {
+		prompt, err := findPromptTemplate(ctx, version)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errNotFound("Prompt template not found")
+		}
+		return &prompt, err
+	}
+	prompt, err := activePromptTemplate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if prompt == nil {
+		return nil, errBadRequest("template or version is required when no prompt template is active")
+	}
+	return prompt, nil
+}
+
+func nextPromptVersion(ctx context.Context) (int, error) {
+	var latest models.PromptTemplate
+	opts := options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}})
+	err := promptCollection.FindOne(ctx, bson.M{}, opts).Decode(&latest)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 1, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return latest.Version + 1, nil
+}
+
+// activatePromptVersion makes version the only active prompt. Both writes
+// run in one transaction, so readers never see no active prompt and
+// concurrent activations are serialized, the last one winning.
+func activatePromptVersion(ctx context.Context, version int) error {
+	session, err := promptCollection.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(ctx context.Context) (any, error) {
+		filter := bson.M{"active": true, "version": bson.M{"$ne": version}}
+		if _, err := promptCollection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"active": false}}); err != nil {
+			return nil, err
+		}
+		return promptCollection.UpdateOne(ctx, bson.M{"version": version}, bson.M{"$set": bson.M{"active": true}})
+	})
+	return err
+}
+
+func findPromptTemplate(ctx context.Context, version int) (models.PromptTemplate, error) {
+	var prompt models.PromptTemplate
+	err := promptCollection.FindOne(ctx, bson.M{"version": version}).Decode(&prompt)
+	return prompt, err
+}
+
+func promptVersionParam(c *gin.Context) (int, bool) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		respondError(c, errBadRequest("version must be a positive integer"))
+		return 0, false
+	}
+	return version, true
+}
+
+func respondPromptError(c *gin.Context, err error) {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		respondError(c, errNotFound("Prompt template not found"))
+		return
+	}
+	respondError(c, errInternal("Failed to fetch prompt template", err))
+}
+
+// SeedPromptTemplate stores BASE_PROMPT_TEMPLATE as the active version 1 when
+// no prompt has been saved yet, so that the database becomes the source of
+// the prompt from then on.
+func SeedPromptTemplate(ctx context.Context) error {
+	template := os.Getenv("BASE_PROMPT_TEMPLATE")
+	if template == "" {
+		return nil
+	}
+	count, err := promptCollection.CountDocuments(ctx, bson.M{})
+	if err != nil || count > 0 {
+		return err
+	}
+	prompt := models.PromptTemplate{
+		Version:   1,
+		Template:  template,
+		Note:      "Imported from BASE_PROMPT_TEMPLATE",
+		Active:    true,
+		CreatedAt: time.Now(),
+	}
+	if _, err := promptCollection.InsertOne(ctx, prompt); err != nil && !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+	return nil
+}
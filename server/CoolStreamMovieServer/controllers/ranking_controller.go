@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/llm"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+)
+
+// legacyUnrankedValue is the ranking_value that meant "not ranked yet"
+// before the sentinel was declared with the unranked flag.
+const legacyUnrankedValue = 999
+
+func ListRankings() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rankings, err := GetRankings()
+		if err != nil {
+			respondError(c, errInternal("Failed to fetch rankings", err))
+			return
+		}
+		c.JSON(http.StatusOK, rankings)
+	}
+}
+
+// AddRanking adds a step to the ranking scale. Values and names are unique
+// and at most one ranking may be the unranked sentinel.
+func AddRanking(classifier llm.ReviewClassifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ranking models.Ranking
+		if err := c.ShouldBindJSON(&ranking); err != nil {
+			respondError(c, errBadRequest("Invalid input"))
+			return
+		}
+		ranking.RankingName = strings.TrimSpace(ranking.RankingName)
+		if err := validate.Struct(ranking); err != nil {
+			respondError(c, errValidation(err))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		if _, err := rankingCollection.InsertOne(ctx, ranking); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				respondError(c, errConflict("Ranking value, name or unranked sentinel already exists"))
+				return
+			}
+			respondError(c, errInternal("Failed to add ranking", err))
+			return
+		}
+		invalidateRankings()
+		triggerReclassification(classifier)
+		c.JSON(http.StatusCreated, ranking)
+	}
+}
+
+func UpdateRanking(classifier llm.ReviewClassifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rankingValue, err := strconv.Atoi(c.Param("ranking_value"))
+		if err != nil {
+			respondError(c, errBadRequest("ranking_value must be an integer"))
+			return
+		}
+		var req struct {
+			RankingName string `json:"ranking_name" validate:"required"`
+			Unranked    bool   `json:"unranked"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, errBadRequest("Invalid input"))
+			return
+		}
+		req.RankingName = strings.TrimSpace(req.RankingName)
+		if err := validate.Struct(req); err != nil {
+			respondError(c, errValidation(err))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var ranking models.Ranking
+		update := bson.M{"$set": bson.M{"ranking_name": req.RankingName, "unranked": req.Unranked}}
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+		err = rankingCollection.FindOneAndUpdate(ctx, bson.M{"ranking_value": rankingValue}, update, opts).Decode(&ranking)
+		if err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				respondError(c, errConflict("Ranking name or unranked sentinel already exists"))
+				return
+			}
+			if err == mongo.ErrNoDocuments {
+				respondError(c, errNotFound("Ranking not found"))
+				return
+			}
+			respondError(c, errInternal("Failed to update ranking", err))
+			return
+		}
+		invalidateRankings()
+		triggerReclassification(classifier)
+		c.JSON(http.StatusOK, ranking)
+	}
+}
+
+func DeleteRanking(classifier llm.ReviewClassifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rankingValue, err := strconv.Atoi(c.Param("ranking_value"))
+		if err != nil {
+			respondError(c, errBadRequest("ranking_value must be an integer"))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		result, err := rankingCollection.DeleteOne(ctx, bson.M{"ranking_value": rankingValue})
+		if err != nil {
+			respondError(c, errInternal("Failed to delete ranking", err))
+			return
+		}
+		if result.DeletedCount == 0 {
+			respondError(c, errNotFound("Ranking not found"))
+			return
+		}
+		invalidateRankings()
+		triggerReclassification(classifier)
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// triggerReclassification re-scores every admin review against the changed
+// ranking scale with a rerank run, cancelling any run still scoring against
+// the old scale.
+func triggerReclassification(classifier llm.ReviewClassifier) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	if _, err := startRerankRun(ctx, classifier, false, RerankTriggerRankingChange, true); err != nil {
+		log.Error().Err(err).Msg("failed to start review reclassification")
+	}
+}
+
+// MigrateUnrankedSentinel flags the legacy 999 ranking as the unranked
+// sentinel when no ranking declares the sentinel yet.
+func MigrateUnrankedSentinel(ctx context.Context) error {
+	count, err := rankingCollection.CountDocuments(ctx, bson.M{"unranked": true})
+	if err != nil || count > 0 {
+		return err
+	}
+	filter := bson.M{"ranking_value": legacyUnrankedValue}
+	_, err = rankingCollection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"unranked": true}})
+	invalidateRankings()
+	return err
+}
@@ -0,0 +1,337 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/database"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/llm"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+)
+
+const (
+	rerankBatchSize          = 100
+	rerankHeartbeatInterval  = 15 * time.Second
+	rerankStaleAfter         = time.Minute
+	defaultRerankConcurrency = 4
+	defaultRerankRate        = 2.0
+)
+
+const (
+	RerankTriggerAdmin         = "admin"
+	RerankTriggerRankingChange = "ranking_change"
+)
+
+var (
+	rerankRunCollection  *mongo.Collection = database.OpenCollection("rerank_runs")
+	rerankDiffCollection *mongo.Collection = database.OpenCollection("rerank_diffs")
+	// rerankOwner identifies this server process as the owner of the runs it
+	// executes.
+	rerankOwner = bson.NewObjectID().Hex()
+)
+
+type rerankCounts struct {
+	processed, changed, unchanged, failed int64
+	lastError                             string
+}
+
+// rerankSettings reads RERANK_CONCURRENCY, the number of reviews classified
+// at once, and RERANK_RATE, the classifications allowed per second.
+func rerankSettings() (int, float64) {
+	concurrency, err := strconv.Atoi(os.Getenv("RERANK_CONCURRENCY"))
+	if err != nil || concurrency < 1 {
+		concurrency = defaultRerankConcurrency
+	}
+	rate, err := strconv.ParseFloat(os.Getenv("RERANK_RATE"), 64)
+	if err != nil || rate <= 0 {
+		rate = defaultRerankRate
+	}
+	return concurrency, rate
+}
+
+// startRerankRun records a new run and executes it in the background. Only
+// one run may be running at a time: with supersede the running one is
+// cancelled first, otherwise starting fails with a duplicate key error.
+func startRerankRun(ctx context.Context, classifier llm.ReviewClassifier, dryRun bool, trigger string, supersede bool) (models.RerankRun, error) {
+	now := time.Now()
+	if supersede {
+		update := bson.M{"$set": bson.M{"status": models.RerankCancelled, "finished_at": now}}
+		if _, err := rerankRunCollection.UpdateMany(ctx, bson.M{"status": models.RerankRunning}, update); err != nil {
+			return models.RerankRun{}, err
+		}
+	}
+
+	total, err := movieCollection.CountDocuments(ctx, reviewedMoviesFilter())
+	if err != nil {
+		return models.RerankRun{}, err
+	}
+	run := models.RerankRun{
+		ID:          bson.NewObjectID(),
+		Status:      models.RerankRunning,
+		DryRun:      dryRun,
+		Trigger:     trigger,
+		Owner:       rerankOwner,
+		Total:       total,
+		StartedAt:   now,
+		HeartbeatAt: now,
+	}
+	if _, err := rerankRunCollection.InsertOne(ctx, run); err != nil {
+		return models.RerankRun{}, err
+	}
+	log.Info().Str("runID", run.ID.Hex()).Bool("dryRun", dryRun).Str("trigger", trigger).Int64("total", total).Msg("rerank started")
+	go executeRerankRun(context.Background(), classifier, run)
+	return run, nil
+}
+
+// claimStaleRerankRun takes over a running run whose owner stopped sending
+// heartbeats. It returns mongo.ErrNoDocuments when there is none.
+func claimStaleRerankRun(ctx context.Context, filter bson.M) (models.RerankRun, error) {
+	now := time.Now()
+	filter["status"] = models.RerankRunning
+	filter["heartbeat_at"] = bson.M{"$lt": now.Add(-rerankStaleAfter)}
+	update := bson.M{"$set": bson.M{"owner": rerankOwner, "heartbeat_at": now}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var run models.RerankRun
+	err := rerankRunCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&run)
+	return run, err
+}
+
+// WatchRerankRuns continues runs left behind by a crashed or restarted
+// server, checking until ctx is cancelled. Any instance may pick them up.
+func WatchRerankRuns(ctx context.Context, classifier llm.ReviewClassifier) {
+	ticker := time.NewTicker(rerankStaleAfter)
+	defer ticker.Stop()
+	for {
+		run, err := claimStaleRerankRun(ctx, bson.M{})
+		switch {
+		case err == nil:
+			log.Info().Str("runID", run.ID.Hex()).Int64("processed", run.Processed).Msg("rerank resumed")
+			go executeRerankRun(ctx, classifier, run)
+		case !errors.Is(err, mongo.ErrNoDocuments) && ctx.Err() == nil:
+			log.Error().Err(err).Msg("failed to look for stalled reranks")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func reviewedMoviesFilter() bson.M {
+	return bson.M{"admin_review": bson.M{"$nin": bson.A{"", nil}}}
+}
+
+// ownedRunFilter matches run only while this process still owns it and it
+// has not been cancelled.
+func ownedRunFilter(runID bson.ObjectID) bson.M {
+	return bson.M{"_id": runID, "owner": rerankOwner, "status": models.RerankRunning}
+}
+
+func executeRerankRun(ctx context.Context, classifier llm.ReviewClassifier, run models.RerankRun) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go rerankHeartbeat(ctx, cancel, run.ID)
+
+	concurrency, rate := rerankSettings()
+	limiter := time.NewTicker(time.Duration(float64(time.Second) / rate))
+	defer limiter.Stop()
+
+	checkpoint := run.Checkpoint
+	for {
+		movies, err := nextRerankBatch(ctx, checkpoint)
+		if err != nil {
+			if ctx.Err() == nil {
+				finishRerankRun(run.ID, models.RerankFailed, err.Error())
+			}
+			return
+		}
+		if len(movies) == 0 {
+			finishRerankRun(run.ID, models.RerankCompleted, "")
+			return
+		}
+
+		counts := rerankBatch(ctx, classifier, run, movies, concurrency, limiter.C)
+		if ctx.Err() != nil {
+			// The batch is redone on resume; its writes are idempotent.
+			return
+		}
+		checkpoint = movies[len(movies)-1].ID
+		set := bson.M{"checkpoint": checkpoint, "heartbeat_at": time.Now()}
+		if counts.lastError != "" {
+			set["last_error"] = counts.lastError
+		}
+		update := bson.M{
+			"$set": set,
+			"$inc": bson.M{
+				"processed": counts.processed,
+				"changed":   counts.changed,
+				"unchanged": counts.unchanged,
+				"failed":    counts.failed,
+			},
+		}
+		result, err := rerankRunCollection.UpdateOne(ctx, ownedRunFilter(run.ID), update)
+		if err != nil {
+			log.Error().Err(err).Str("runID", run.ID.Hex()).Msg("failed to checkpoint rerank")
+			return
+		}
+		if result.MatchedCount == 0 {
+			log.Info().Str("runID", run.ID.Hex()).Msg("rerank stopped, run was cancelled or taken over")
+			return
+		}
+	}
+}
+
+// rerankHeartbeat keeps the run owned by this process and cancels ctx once it
+// is not, which is how a cancellation through the API reaches the workers.
+func rerankHeartbeat(ctx context.Context, cancel context.CancelFunc, runID bson.ObjectID) {
+	ticker := time.NewTicker(rerankHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		update := bson.M{"$set": bson.M{"heartbeat_at": time.Now()}}
+		result, err := rerankRunCollection.UpdateOne(ctx, ownedRunFilter(runID), update)
+		if err != nil {
+			log.Warn().Err(err).Str("runID", runID.Hex()).Msg("rerank heartbeat failed")
+			continue
+		}
+		if result.MatchedCount == 0 {
+			cancel()
+			return
+		}
+	}
+}
+
+func nextRerankBatch(ctx context.Context, checkpoint bson.ObjectID) ([]models.Movie, error) {
+	filter := reviewedMoviesFilter()
+	if !checkpoint.IsZero() {
+		filter["_id"] = bson.M{"$gt": checkpoint}
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(rerankBatchSize).
+		SetProjection(bson.M{"imdb_id": 1, "title": 1, "genre": 1, "admin_review": 1, "ranking": 1})
+	cursor, err := movieCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var movies []models.Movie
+	if err := cursor.All(ctx, &movies); err != nil {
+		return nil, err
+	}
+	return movies, nil
+}
+
+// rerankBatch classifies movies with at most concurrency calls in flight,
+// each waiting for a tick of limiter.
+func rerankBatch(ctx context.Context, classifier llm.ReviewClassifier, run models.RerankRun, movies []models.Movie, concurrency int, limiter <-chan time.Time) rerankCounts {
+	var (
+		counts rerankCounts
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+	)
+	slots := make(chan struct{}, concurrency)
+	for _, movie := range movies {
+		select {
+		case <-ctx.Done():
+		case <-limiter:
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-slots; wg.Done() }()
+			changed, err := rerankMovie(ctx, classifier, run, movie)
+
+			mu.Lock()
+			defer mu.Unlock()
+			counts.processed++
+			switch {
+			case err != nil:
+				counts.failed++
+				counts.lastError = err.Error()
+				log.Warn().Err(err).Str("imdbID", movie.ImdbID).Msg("failed to rerank review")
+			case changed:
+				counts.changed++
+			default:
+				counts.unchanged++
+			}
+		}()
+	}
+	wg.Wait()
+	return counts
+}
+
+// rerankMovie classifies the movie's review and reports whether its ranking
+// changes. A dry run records the change as a diff instead of applying it.
+func rerankMovie(ctx context.Context, classifier llm.ReviewClassifier, run models.RerankRun, movie models.Movie) (bool, error) {
+	classifyCtx, cancel := context.WithTimeout(ctx, reviewJobTimeout)
+	defer cancel()
+	classification, err := GetReviewRanking(classifyCtx, classifier, movie, movie.AdminReview)
+	if err != nil {
+		return false, err
+	}
+	ranking := classification.Ranking
+	if ranking.RankingValue == movie.Ranking.RankingValue && ranking.RankingName == movie.Ranking.RankingName {
+		return false, nil
+	}
+
+	if run.DryRun {
+		diff := models.RerankDiff{
+			RunID:      run.ID,
+			ImdbID:     movie.ImdbID,
+			Title:      movie.Title,
+			OldRanking: movie.Ranking,
+			NewRanking: ranking,
+			CreatedAt:  time.Now(),
+		}
+		filter := bson.M{"run_id": run.ID, "imdb_id": movie.ImdbID}
+		_, err := rerankDiffCollection.ReplaceOne(ctx, filter, diff, options.Replace().SetUpsert(true))
+		return true, err
+	}
+
+	// Skip the write if the review was edited meanwhile.
+	filter := bson.M{"_id": movie.ID, "admin_review": movie.AdminReview}
+	update := bson.M{
+		"$set": bson.M{
+			"ranking":        ranking,
+			"ranking_status": models.RankingRanked,
+			"classification": newReviewClassification(classification),
+		},
+		"$inc": bson.M{"version": 1},
+	}
+	_, err = movieCollection.UpdateOne(ctx, filter, update)
+	return true, err
+}
+
+func finishRerankRun(runID bson.ObjectID, status, lastError string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	set := bson.M{"status": status, "finished_at": time.Now()}
+	if lastError != "" {
+		set["last_error"] = lastError
+	}
+	if _, err := rerankRunCollection.UpdateOne(ctx, ownedRunFilter(runID), bson.M{"$set": set}); err != nil {
+		log.Error().Err(err).Str("runID", runID.Hex()).Msg("failed to finish rerank")
+		return
+	}
+	log.Info().Str("runID", runID.Hex()).Str("status", status).Msg("rerank finished")
+}
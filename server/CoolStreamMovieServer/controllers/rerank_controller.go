@@ -0,0 +1,241 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/llm"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+)
+
+// rerankRunResponse adds the completed share of the run.
+type rerankRunResponse struct {
+	models.RerankRun
+	Progress float64 `json:"progress"`
+}
+
+func newRerankRunResponse(run models.RerankRun) rerankRunResponse {
+	progress := 1.0
+	if run.Status != models.RerankCompleted && run.Total > 0 {
+		progress = min(float64(run.Processed)/float64(run.Total), 1)
+	}
+	return rerankRunResponse{RerankRun: run, Progress: progress}
+}
+
+// StartRerank re-classifies every admin review in the background. With
+// {"dry_run": true} rankings are left alone and the changes are listed under
+// /admin/rerank/:run_id/diffs instead.
+func StartRerank(classifier llm.ReviewClassifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			DryRun bool `json:"dry_run"`
+		}
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				respondError(c, errBadRequest("Invalid request body"))
+				return
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		run, err := startRerankRun(ctx, classifier, req.DryRun, RerankTriggerAdmin, false)
+		if err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				respondError(c, errConflict("A rerank is already running"))
+				return
+			}
+			respondError(c, errInternal("Failed to start rerank", err))
+			return
+		}
+		c.Header("Location", "/admin/rerank/"+run.ID.Hex())
+		c.JSON(http.StatusAccepted, newRerankRunResponse(run))
+	}
+}
+
+func ListRerankRuns() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, limit, err := parsePagination(c)
+		if err != nil {
+			respondError(c, errBadRequest(err.Error()))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		total, err := rerankRunCollection.CountDocuments(ctx, bson.M{})
+		if err != nil {
+			respondError(c, errInternal("Failed to count rerank runs", err))
+			return
+		}
+		findOptions := options.Find().
+			SetSort(bson.D{{Key: "started_at", Value: -1}}).
+			SetSkip((page - 1) * limit).
+			SetLimit(limit)
+		cursor, err := rerankRunCollection.Find(ctx, bson.M{}, findOptions)
+		if err != nil {
+			respondError(c, errInternal("Failed to fetch rerank runs", err))
+			return
+		}
+		defer cursor.Close(ctx)
+
+		var runs []models.RerankRun
+		if err := cursor.All(ctx, &runs); err != nil {
+			respondError(c, errInternal("Failed to decode rerank runs", err))
+			return
+		}
+		responses := make([]rerankRunResponse, 0, len(runs))
+		for _, run := range runs {
+			responses = append(responses, newRerankRunResponse(run))
+		}
+		c.JSON(http.StatusOK, gin.H{"runs": responses, "meta": newPageMeta(page, limit, total)})
+	}
+}
+
+func GetRerankRun() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		runID, ok := rerankRunIDParam(c)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var run models.RerankRun
+		if err := rerankRunCollection.FindOne(ctx, bson.M{"_id": runID}).Decode(&run); err != nil {
+			respondRerankRunError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, newRerankRunResponse(run))
+	}
+}
+
+func ListRerankDiffs() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		runID, ok := rerankRunIDParam(c)
+		if !ok {
+			return
+		}
+		page, limit, err := parsePagination(c)
+		if err != nil {
+			respondError(c, errBadRequest(err.Error()))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		filter := bson.M{"run_id": runID}
+		total, err := rerankDiffCollection.CountDocuments(ctx, filter)
+		if err != nil {
+			respondError(c, errInternal("Failed to count rerank diffs", err))
+			return
+		}
+		findOptions := options.Find().
+			SetSort(bson.D{{Key: "imdb_id", Value: 1}}).
+			SetSkip((page - 1) * limit).
+			SetLimit(limit)
+		cursor, err := rerankDiffCollection.Find(ctx, filter, findOptions)
+		if err != nil {
+			respondError(c, errInternal("Failed to fetch rerank diffs", err))
+			return
+		}
+		defer cursor.Close(ctx)
+
+		diffs := []models.RerankDiff{}
+		if err := cursor.All(ctx, &diffs); err != nil {
+			respondError(c, errInternal("Failed to decode rerank diffs", err))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"diffs": diffs, "meta": newPageMeta(page, limit, total)})
+	}
+}
+
+// CancelRerank stops a running run. Its owner notices on the next heartbeat
+// and lets the batch in flight finish.
+func CancelRerank() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		runID, ok := rerankRunIDParam(c)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		update := bson.M{"$set": bson.M{"status": models.RerankCancelled, "finished_at": time.Now()}}
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+		var run models.RerankRun
+		err := rerankRunCollection.FindOneAndUpdate(ctx, bson.M{"_id": runID, "status": models.RerankRunning}, update, opts).Decode(&run)
+		if err != nil {
+			respondRerankStateError(ctx, c, runID, err, "Only running reranks can be cancelled")
+			return
+		}
+		c.JSON(http.StatusOK, newRerankRunResponse(run))
+	}
+}
+
+// ResumeRerank takes over a running run whose server stopped sending
+// heartbeats and continues it from its checkpoint.
+func ResumeRerank(classifier llm.ReviewClassifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		runID, ok := rerankRunIDParam(c)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		run, err := claimStaleRerankRun(ctx, bson.M{"_id": runID})
+		if err != nil {
+			respondRerankStateError(ctx, c, runID, err, "Only stalled running reranks can be resumed")
+			return
+		}
+		go executeRerankRun(context.Background(), classifier, run)
+		c.JSON(http.StatusAccepted, newRerankRunResponse(run))
+	}
+}
+
+func rerankRunIDParam(c *gin.Context) (bson.ObjectID, bool) {
+	runID, err := bson.ObjectIDFromHex(c.Param("run_id"))
+	if err != nil {
+		respondError(c, errBadRequest("run_id is not a valid id"))
+		return bson.ObjectID{}, false
+	}
+	return runID, true
+}
+
+func respondRerankRunError(c *gin.Context, err error) {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		respondError(c, errNotFound("Rerank run not found"))
+		return
+	}
+	respondError(c, errInternal("Failed to fetch rerank run", err))
+}
+
+// respondRerankStateError tells a missing run (404) from one in the wrong
+// state (409) after a conditional update matched nothing.
+func respondRerankStateError(ctx context.Context, c *gin.Context, runID bson.ObjectID, err error, conflict string) {
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		respondError(c, errInternal("Failed to update rerank run", err))
+		return
+	}
+	count, err := rerankRunCollection.CountDocuments(ctx, bson.M{"_id": runID})
+	if err != nil {
+		respondError(c, errInternal("Failed to fetch rerank run", err))
+		return
+	}
+	if count == 0 {
+		respondError(c, errNotFound("Rerank run not found"))
+		return
+	}
+	respondError(c, errConflict(conflict))
+}
@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/database"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+)
+
+var resetRequestCollection *mongo.Collection = database.OpenCollection("password_reset_requests")
+
+// resetRequestWindow is how long a password reset request counts against
+// its limits.
+const resetRequestWindow = time.Hour
+
+// resetLimit is the number of password reset requests a scope allows within
+// resetRequestWindow, so that the endpoint cannot be used to flood an inbox.
+// Many users can share an IP, so it gets more room than an address.
+type resetLimit struct {
+	scope       string
+	maxRequests int
+}
+
+var (
+	emailResetLimit = resetLimit{scope: "email", maxRequests: 3}
+	ipResetLimit    = resetLimit{scope: "ip", maxRequests: 10}
+)
+
+type resetKey struct {
+	limit resetLimit
+	key   string
+}
+
+// respondIfResetThrottled writes a 429 with Retry-After and reports true when
+// the address or the client IP used up its reset requests. Otherwise the
+// request is counted against both.
+func respondIfResetThrottled(ctx context.Context, c *gin.Context, email string) bool {
+	email = strings.ToLower(strings.TrimSpace(email))
+	ip := c.ClientIP()
+	keys := []resetKey{
+		{limit: emailResetLimit, key: "reset-email:" + email},
+		{limit: ipResetLimit, key: "reset-ip:" + ip},
+	}
+
+	now := time.Now()
+	var wait time.Duration
+	for _, key := range keys {
+		filter := bson.M{"key": key.key, "expires_at": bson.M{"$gt": now}}
+		count, err := resetRequestCollection.CountDocuments(ctx, filter)
+		if err != nil {
+			respondError(c, errInternal("Failed to check password reset requests", err))
+			return true
+		}
+		if int(count) < key.limit.maxRequests {
+			continue
+		}
+		var oldest models.PasswordResetRequest
+		opts := options.FindOne().SetSort(bson.D{{Key: "expires_at", Value: 1}})
+		if err := resetRequestCollection.FindOne(ctx, filter, opts).Decode(&oldest); err != nil {
+			respondError(c, errInternal("Failed to check password reset requests", err))
+			return true
+		}
+		wait = max(wait, oldest.ExpiresAt.Sub(now))
+		log.Warn().Str("scope", key.limit.scope).Str("email", email).Str("ip", ip).Msg("password reset requests throttled")
+	}
+	if wait > 0 {
+		seconds := int((wait + time.Second - 1) / time.Second)
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		respondError(c, errTooManyRequests("Too many password reset requests, try again later"))
+		return true
+	}
+
+	requests := make([]models.PasswordResetRequest, 0, len(keys))
+	for _, key := range keys {
+		requests = append(requests, models.PasswordResetRequest{Key: key.key, ExpiresAt: now.Add(resetRequestWindow)})
+	}
+	if _, err := resetRequestCollection.InsertMany(ctx, requests); err != nil {
+		log.Error().Err(err).Msg("failed to record password reset request")
+	}
+	return false
+}
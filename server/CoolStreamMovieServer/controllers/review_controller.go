@@ -0,0 +1,373 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/database"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/moderation"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/utils"
+)
+
+var reviewCollection *mongo.Collection = database.OpenCollection("reviews")
+
+type reviewRequest struct {
+	Rating int    `json:"rating"`
+	Text   string `json:"text"`
+}
+
+// publishedReviews matches reviews visible to everyone; reviews stored before
+// moderation existed have no status.
+var publishedReviews = bson.M{"$nin": bson.A{models.ReviewFlagged, models.ReviewRejected}}
+
+// GetMovieReviews lists the published user reviews of a movie, newest first.
+func GetMovieReviews() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		movieID := c.Param("imdb_id")
+		page, limit, err := parsePagination(c)
+		if err != nil {
+			respondError(c, errBadRequest(err.Error()))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		if _, err := findReviewedMovie(ctx, movieID); err != nil {
+			respondError(c, err)
+			return
+		}
+		filter := bson.M{"imdb_id": movieID, "status": publishedReviews}
+		total, err := reviewCollection.CountDocuments(ctx, filter)
+		if err != nil {
+			respondError(c, errInternal("Failed to count reviews", err))
+			return
+		}
+		findOptions := options.Find().
+			SetSort(bson.D{{Key: "created_at", Value: -1}}).
+			SetSkip((page - 1) * limit).
+			SetLimit(limit)
+		cursor, err := reviewCollection.Find(ctx, filter, findOptions)
+		if err != nil {
+			respondError(c, errInternal("Failed to fetch reviews", err))
+			return
+		}
+		defer cursor.Close(ctx)
+
+		var stored []models.Review
+		if err := cursor.All(ctx, &stored); err != nil {
+			respondError(c, errInternal("Failed to decode reviews", err))
+			return
+		}
+		reviews := make([]models.PublicReview, 0, len(stored))
+		for _, review := range stored {
+			reviews = append(reviews, toPublicReview(review))
+		}
+		c.JSON(http.StatusOK, gin.H{"reviews": reviews, "meta": newPageMeta(page, limit, total)})
+	}
+}
+
+// CreateReview posts the caller's review of a movie. Texts the moderator
+// flags wait for a moderation decision before they are published.
+// With classify set, the review's sentiment is added by a review worker.
+func CreateReview(classify bool, moderator *moderation.Pipeline) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := utils.GetClaimsFromContext(c)
+		if err != nil {
+			respondError(c, errUnauthorized("Authentication required"))
+			return
+		}
+		var req reviewRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, errBadRequest("Invalid request body"))
+			return
+		}
+		now := time.Now()
+		review := models.Review{
+			ImdbID:    c.Param("imdb_id"),
+			UserID:    claims.UID,
+			Author:    reviewAuthor(claims.FirstName, claims.LastName),
+			Rating:    req.Rating,
+			Text:      strings.TrimSpace(req.Text),
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if err := validate.Struct(review); err != nil {
+			respondError(c, errValidation(err))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		if _, err := findReviewedMovie(ctx, review.ImdbID); err != nil {
+			respondError(c, err)
+			return
+		}
+		moderateReview(ctx, moderator, &review)
+		result, err := reviewCollection.InsertOne(ctx, review)
+		if err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				respondError(c, errConflict("You have already reviewed this movie"))
+				return
+			}
+			respondError(c, errInternal("Failed to save review", err))
+			return
+		}
+		review.ID = result.InsertedID.(bson.ObjectID)
+
+		applyReviewChange(ctx, nil, &review)
+		if classify && review.Text != "" {
+			queueUserReviewClassification(ctx, review)
+		}
+		c.JSON(http.StatusCreated, review)
+	}
+}
+
+// errReviewRejected refuses edits of a review a moderator rejected, which
+// would otherwise publish it again.
+var errReviewRejected = errForbidden("Rejected reviews cannot be edited")
+
+// UpdateReview replaces the rating and text of the caller's review, which is
+// moderated again. The previous moderation, including any moderator
+// decision, is kept in the review's moderation history.
+func UpdateReview(classify bool, moderator *moderation.Pipeline) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			respondError(c, errUnauthorized("Authentication required"))
+			return
+		}
+		var req reviewRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, errBadRequest("Invalid request body"))
+			return
+		}
+		update := models.Review{Rating: req.Rating, Text: strings.TrimSpace(req.Text)}
+		if err := validate.Struct(update); err != nil {
+			respondError(c, errValidation(err))
+			return
+		}
+		movieID := c.Param("imdb_id")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		if _, err := findReviewedMovie(ctx, movieID); err != nil {
+			respondError(c, err)
+			return
+		}
+		var current models.Review
+		err = reviewCollection.FindOne(ctx, bson.M{"imdb_id": movieID, "user_id": userID}).Decode(&current)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				respondError(c, errNotFound("Review not found"))
+				return
+			}
+			respondError(c, errInternal("Failed to fetch review", err))
+			return
+		}
+		if current.Status == models.ReviewRejected {
+			respondError(c, errReviewRejected)
+			return
+		}
+
+		update.UpdatedAt = time.Now()
+		moderateReview(ctx, moderator, &update)
+		set := bson.M{"rating": update.Rating, "text": update.Text, "status": update.Status, "updated_at": update.UpdatedAt}
+		unset := bson.M{"sentiment": "", "classification": ""}
+		if update.Moderation != nil {
+			set["moderation"] = update.Moderation
+		} else {
+			unset["moderation"] = ""
+		}
+		change := bson.M{"$set": set, "$unset": unset}
+		if current.Moderation != nil {
+			change["$push"] = bson.M{"moderation_history": current.Moderation}
+		}
+
+		// Every moderator decision changes the status, so matching the status
+		// that was read keeps a decision made meanwhile from being lost.
+		filter := bson.M{"_id": current.ID, "status": current.Status, "updated_at": current.UpdatedAt}
+		var previous models.Review
+		err = reviewCollection.FindOneAndUpdate(ctx, filter, change).Decode(&previous)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				respondError(c, errConflict("Review was changed meanwhile, try again"))
+				return
+			}
+			respondError(c, errInternal("Failed to update review", err))
+			return
+		}
+
+		review := previous
+		review.Rating, review.Text, review.Status, review.Moderation = update.Rating, update.Text, update.Status, update.Moderation
+		review.UpdatedAt = update.UpdatedAt
+		review.Sentiment, review.Classification = nil, nil
+		if previous.Moderation != nil {
+			review.ModerationHistory = append(review.ModerationHistory, *previous.Moderation)
+		}
+		applyReviewChange(ctx, &previous, &review)
+
+		if classify && review.Text != "" {
+			queueUserReviewClassification(ctx, review)
+		}
+		c.JSON(http.StatusOK, review)
+	}
+}
+
+func DeleteReview() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			respondError(c, errUnauthorized("Authentication required"))
+			return
+		}
+		movieID := c.Param("imdb_id")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var review models.Review
+		err = reviewCollection.FindOneAndDelete(ctx, bson.M{"imdb_id": movieID, "user_id": userID}).Decode(&review)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				respondError(c, errNotFound("Review not found"))
+				return
+			}
+			respondError(c, errInternal("Failed to delete review", err))
+			return
+		}
+		applyReviewChange(ctx, &review, nil)
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// moderateReview runs the moderation checks over the text of review and sets
+// its status. Rating-only reviews have nothing to moderate.
+func moderateReview(ctx context.Context, moderator *moderation.Pipeline, review *models.Review) {
+	review.Status, review.Moderation = models.ReviewPublished, nil
+	if moderator == nil || review.Text == "" {
+		return
+	}
+	findings := moderator.Moderate(ctx, review.Text)
+	if len(findings) == 0 {
+		return
+	}
+	review.Status = models.ReviewFlagged
+	review.Moderation = &models.ReviewModeration{Findings: findings, CheckedAt: time.Now()}
+}
+
+// ratingContribution is what a review adds to its movie's rating sum,
+// rating count and review count. Unpublished and missing reviews add nothing.
+func ratingContribution(review *models.Review) (int64, int64, int64) {
+	if review == nil || review.Status == models.ReviewFlagged || review.Status == models.ReviewRejected {
+		return 0, 0, 0
+	}
+	return int64(review.Rating), 1, textCount(review.Text)
+}
+
+// applyReviewChange moves the movie's ratings from the contribution of
+// before to that of after; either may be nil. A failure is logged since the
+// review itself has already been written.
+func applyReviewChange(ctx context.Context, before, after *models.Review) {
+	beforeSum, beforeCount, beforeReviews := ratingContribution(before)
+	afterSum, afterCount, afterReviews := ratingContribution(after)
+	if beforeSum == afterSum && beforeCount == afterCount && beforeReviews == afterReviews {
+		return
+	}
+	movieID := ""
+	if after != nil {
+		movieID = after.ImdbID
+	} else {
+		movieID = before.ImdbID
+	}
+	err := applyRatingDelta(ctx, movieID, afterSum-beforeSum, afterCount-beforeCount, afterReviews-beforeReviews)
+	if err != nil {
+		log.Error().Err(err).Str("imdbID", movieID).Msg("failed to update movie rating")
+	}
+}
+
+// applyRatingDelta adjusts the rating totals of a movie and recomputes its
+// average in a single atomic update, so concurrent reviews cannot lose each
+// other's counts.
+func applyRatingDelta(ctx context.Context, movieID string, ratingDelta, countDelta, reviewDelta int64) error {
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"rating_sum":   bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$rating_sum", 0}}, ratingDelta}},
+			"rating_count": bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$rating_count", 0}}, countDelta}},
+			"review_count": bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$review_count", 0}}, reviewDelta}},
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"average_rating": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{"$rating_count", 0}},
+				bson.M{"$round": bson.A{bson.M{"$divide": bson.A{"$rating_sum", "$rating_count"}}, 2}},
+				0,
+			}},
+		}}},
+	}
+	_, err := movieCollection.UpdateOne(ctx, bson.M{"imdb_id": movieID}, pipeline)
+	return err
+}
+
+// queueUserReviewClassification queues the sentiment of review. The review
+// is saved either way, so a failure is only logged.
+func queueUserReviewClassification(ctx context.Context, review models.Review) {
+	if err := enqueueUserReviewJob(ctx, review); err != nil {
+		log.Error().Err(err).Str("reviewID", review.ID.Hex()).Msg("failed to queue user review classification")
+	}
+}
+
+// findReviewedMovie loads the fields of a movie its reviews need, as an
+// APIError when it does not exist.
+func findReviewedMovie(ctx context.Context, movieID string) (models.Movie, error) {
+	var movie models.Movie
+	opts := options.FindOne().SetProjection(bson.M{"imdb_id": 1, "title": 1, "genre": 1})
+	err := movieCollection.FindOne(ctx, bson.M{"imdb_id": movieID}, opts).Decode(&movie)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return movie, errNotFound("Movie not found")
+	}
+	if err != nil {
+		return movie, errInternal("Failed to fetch movie", err)
+	}
+	return movie, nil
+}
+
+func toPublicReview(review models.Review) models.PublicReview {
+	return models.PublicReview{
+		ID:        review.ID,
+		ImdbID:    review.ImdbID,
+		Author:    review.Author,
+		Rating:    review.Rating,
+		Text:      review.Text,
+		Sentiment: review.Sentiment,
+		CreatedAt: review.CreatedAt,
+		UpdatedAt: review.UpdatedAt,
+	}
+}
+
+// reviewAuthor shows reviewers by first name and last initial.
+func reviewAuthor(firstName, lastName string) string {
+	initial, _ := utf8.DecodeRuneInString(lastName)
+	if initial == utf8.RuneError {
+		return firstName
+	}
+	return firstName + " " + string(initial) + "."
+}
+
+func textCount(text string) int64 {
+	if text == "" {
+		return 0
+	}
+	return 1
+}
@@ -0,0 +1,203 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+)
+
+const (
+	reviewJobEventsTimeout   = 10 * time.Minute
+	reviewJobEventsKeepalive = 15 * time.Second
+)
+
+func GetReviewJob() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		jobID, ok := reviewJobIDParam(c)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		job, err := findReviewJob(ctx, jobID)
+		if err != nil {
+			respondReviewJobError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, job)
+	}
+}
+
+// ReviewJobEvents streams the job as server-sent "status" events whenever it
+// changes, and closes the stream once the job is finished.
+func ReviewJobEvents() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		jobID, ok := reviewJobIDParam(c)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), reviewJobEventsTimeout)
+		defer cancel()
+
+		job, err := findReviewJob(ctx, jobID)
+		if err != nil {
+			respondReviewJobError(c, err)
+			return
+		}
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no")
+
+		ticker := time.NewTicker(reviewJobPollInterval)
+		defer ticker.Stop()
+		lastSent := time.Now()
+		var lastUpdate time.Time
+		for {
+			if !job.UpdatedAt.Equal(lastUpdate) {
+				c.SSEvent("status", job)
+				c.Writer.Flush()
+				lastUpdate, lastSent = job.UpdatedAt, time.Now()
+			}
+			if job.Finished() {
+				return
+			}
+			if time.Since(lastSent) >= reviewJobEventsKeepalive {
+				_, _ = c.Writer.WriteString(": keepalive\n\n")
+				c.Writer.Flush()
+				lastSent = time.Now()
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if job, err = findReviewJob(ctx, jobID); err != nil {
+				if ctx.Err() == nil {
+					log.Error().Err(err).Str("jobID", jobID.Hex()).Msg("failed to poll review job")
+					c.SSEvent("error", gin.H{"error": "Failed to fetch review job"})
+					c.Writer.Flush()
+				}
+				return
+			}
+		}
+	}
+}
+
+// ListReviewJobs lists jobs newest first, optionally by status and imdb_id.
+// ?status=dead is the dead-letter queue.
+func ListReviewJobs() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, limit, err := parsePagination(c)
+		if err != nil {
+			respondError(c, errBadRequest(err.Error()))
+			return
+		}
+		filter := bson.M{}
+		if status := c.Query("status"); status != "" {
+			filter["status"] = status
+		}
+		if imdbID := c.Query("imdb_id"); imdbID != "" {
+			filter["imdb_id"] = imdbID
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		total, err := reviewJobCollection.CountDocuments(ctx, filter)
+		if err != nil {
+			respondError(c, errInternal("Failed to count review jobs", err))
+			return
+		}
+		findOptions := options.Find().
+			SetSort(bson.D{{Key: "created_at", Value: -1}}).
+			SetSkip((page - 1) * limit).
+			SetLimit(limit)
+		cursor, err := reviewJobCollection.Find(ctx, filter, findOptions)
+		if err != nil {
+			respondError(c, errInternal("Failed to fetch review jobs", err))
+			return
+		}
+		defer cursor.Close(ctx)
+
+		jobs := []models.ReviewJob{}
+		if err := cursor.All(ctx, &jobs); err != nil {
+			respondError(c, errInternal("Failed to decode review jobs", err))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"jobs": jobs, "meta": newPageMeta(page, limit, total)})
+	}
+}
+
+// RetryReviewJob requeues a dead job with a fresh set of attempts.
+func RetryReviewJob() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		jobID, ok := reviewJobIDParam(c)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		now := time.Now()
+		update := bson.M{"$set": bson.M{"status": models.JobQueued, "attempts": 0, "run_at": now, "updated_at": now}}
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+		var job models.ReviewJob
+		err := reviewJobCollection.FindOneAndUpdate(ctx, bson.M{"_id": jobID, "status": models.JobDead}, update, opts).Decode(&job)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			if _, err := findReviewJob(ctx, jobID); err != nil {
+				respondReviewJobError(c, err)
+				return
+			}
+			respondError(c, errConflict("Only dead review jobs can be retried"))
+			return
+		}
+		if err != nil {
+			respondError(c, errInternal("Failed to retry review job", err))
+			return
+		}
+
+		_, err = movieCollection.UpdateOne(ctx,
+			reviewJobMovieFilter(job),
+			bson.M{"$set": bson.M{"ranking_status": models.RankingPending}})
+		if err != nil {
+			log.Error().Err(err).Str("imdbID", job.ImdbID).Msg("failed to mark movie ranking as pending")
+		}
+		wakeReviewWorker()
+		c.JSON(http.StatusAccepted, job)
+	}
+}
+
+func reviewJobIDParam(c *gin.Context) (bson.ObjectID, bool) {
+	jobID, err := bson.ObjectIDFromHex(c.Param("job_id"))
+	if err != nil {
+		respondError(c, errBadRequest("job_id is not a valid id"))
+		return bson.ObjectID{}, false
+	}
+	return jobID, true
+}
+
+func findReviewJob(ctx context.Context, jobID bson.ObjectID) (models.ReviewJob, error) {
+	var job models.ReviewJob
+	err := reviewJobCollection.FindOne(ctx, bson.M{"_id": jobID}).Decode(&job)
+	return job, err
+}
+
+func respondReviewJobError(c *gin.Context, err error) {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		respondError(c, errNotFound("Review job not found"))
+		return
+	}
+	respondError(c, errInternal("Failed to fetch review job", err))
+}
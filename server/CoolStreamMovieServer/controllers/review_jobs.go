@@ -0,0 +1,336 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/database"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/llm"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+)
+
+const (
+	reviewJobMaxAttempts = 5
+	// reviewJobLease is how long a worker owns a claimed job. A job whose
+	// lease ran out, because its worker crashed, is claimed again.
+	reviewJobLease        = 3 * time.Minute
+	reviewJobTimeout      = 100 * time.Second
+	reviewJobBaseBackoff  = 5 * time.Second
+	reviewJobMaxBackoff   = 10 * time.Minute
+	reviewJobPollInterval = time.Second
+)
+
+var (
+	reviewJobCollection *mongo.Collection = database.OpenCollection("review_jobs")
+	// reviewJobWakeup lets a worker in this process pick up a new job without
+	// waiting for its next poll.
+	reviewJobWakeup = make(chan struct{}, 1)
+)
+
+// enqueueReviewJob stores a queued job for review, held back for one lease
+// so that no worker claims it before the movie points at jobID. The caller
+// then either releases it with releaseReviewJob or, when the movie could not
+// be updated, drops it with supersedeReviewJob. A job that is never released
+// still runs once the hold expires and is superseded by the worker.
+func enqueueReviewJob(ctx context.Context, jobID bson.ObjectID, imdbID, review string) (models.ReviewJob, error) {
+	now := time.Now()
+	job := models.ReviewJob{
+		ID:          jobID,
+		Kind:        models.ReviewJobAdmin,
+		ImdbID:      imdbID,
+		AdminReview: review,
+		Status:      models.JobQueued,
+		MaxAttempts: reviewJobMaxAttempts,
+		RunAt:       now.Add(reviewJobLease),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if _, err := reviewJobCollection.InsertOne(ctx, job); err != nil {
+		return models.ReviewJob{}, err
+	}
+	return job, nil
+}
+
+// enqueueUserReviewJob queues the sentiment classification of a user review,
+// due at once. The job is superseded if the text changes before it runs.
+func enqueueUserReviewJob(ctx context.Context, review models.Review) error {
+	now := time.Now()
+	job := models.ReviewJob{
+		ID:          bson.NewObjectID(),
+		Kind:        models.ReviewJobUser,
+		ImdbID:      review.ImdbID,
+		ReviewID:    &review.ID,
+		ReviewText:  review.Text,
+		Status:      models.JobQueued,
+		MaxAttempts: reviewJobMaxAttempts,
+		RunAt:       now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if _, err := reviewJobCollection.InsertOne(ctx, job); err != nil {
+		return err
+	}
+	wakeReviewWorker()
+	return nil
+}
+
+// releaseReviewJob makes a held job due now and wakes a local worker.
+func releaseReviewJob(ctx context.Context, jobID bson.ObjectID) {
+	now := time.Now()
+	filter := bson.M{"_id": jobID, "status": models.JobQueued}
+	update := bson.M{"$set": bson.M{"run_at": now, "updated_at": now}}
+	if _, err := reviewJobCollection.UpdateOne(ctx, filter, update); err != nil {
+		log.Error().Err(err).Str("jobID", jobID.Hex()).Msg("failed to release review job")
+		return
+	}
+	wakeReviewWorker()
+}
+
+// supersedeReviewJob finishes a held job whose movie was never pointed at it.
+func supersedeReviewJob(ctx context.Context, jobID bson.ObjectID) {
+	now := time.Now()
+	filter := bson.M{"_id": jobID, "status": models.JobQueued}
+	update := bson.M{"$set": bson.M{"status": models.JobSuperseded, "updated_at": now, "completed_at": now}}
+	if _, err := reviewJobCollection.UpdateOne(ctx, filter, update); err != nil {
+		log.Error().Err(err).Str("jobID", jobID.Hex()).Msg("failed to supersede review job")
+	}
+}
+
+func wakeReviewWorker() {
+	select {
+	case reviewJobWakeup <- struct{}{}:
+	default:
+	}
+}
+
+// StartReviewWorkers runs workers goroutines that classify queued review jobs
+// with classifier until ctx is cancelled. Jobs are claimed through Mongo, so
+// any number of server instances can share the queue.
+func StartReviewWorkers(ctx context.Context, classifier llm.ReviewClassifier, workers int) {
+	for range workers {
+		go runReviewWorker(ctx, classifier)
+	}
+}
+
+func runReviewWorker(ctx context.Context, classifier llm.ReviewClassifier) {
+	for {
+		job, err := claimReviewJob(ctx)
+		if err == nil {
+			processReviewJob(ctx, classifier, job)
+			continue
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) && ctx.Err() == nil {
+			log.Error().Err(err).Msg("failed to claim review job")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-reviewJobWakeup:
+		case <-time.After(reviewJobPollInterval):
+		}
+	}
+}
+
+// claimReviewJob takes the oldest due job, or a running job whose lease
+// expired, and leases it to the caller. Every claim counts as an attempt.
+func claimReviewJob(ctx context.Context) (models.ReviewJob, error) {
+	now := time.Now()
+	filter := bson.M{"$or": bson.A{
+		bson.M{"status": models.JobQueued, "run_at": bson.M{"$lte": now}},
+		bson.M{"status": models.JobRunning, "locked_until": bson.M{"$lt": now}},
+	}}
+	update := bson.M{
+		"$set": bson.M{"status": models.JobRunning, "locked_until": now.Add(reviewJobLease), "updated_at": now},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "run_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var job models.ReviewJob
+	err := reviewJobCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	return job, err
+}
+
+func processReviewJob(ctx context.Context, classifier llm.ReviewClassifier, job models.ReviewJob) {
+	if job.Attempts > job.MaxAttempts {
+		failReviewJob(ctx, job, errors.New("lease expired on every attempt"))
+		return
+	}
+	if job.Kind == models.ReviewJobUser {
+		processUserReviewJob(ctx, classifier, job)
+		return
+	}
+	var movie models.Movie
+	err := movieCollection.FindOne(ctx, reviewJobMovieFilter(job)).Decode(&movie)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// The movie was deleted or given a newer review.
+		completeReviewJob(ctx, job, nil)
+		return
+	}
+	if err != nil {
+		failReviewJob(ctx, job, err)
+		return
+	}
+
+	jobCtx, cancel := context.WithTimeout(ctx, reviewJobTimeout)
+	classification, err := GetReviewRanking(jobCtx, classifier, movie, job.AdminReview)
+	cancel()
+	if err != nil {
+		failReviewJob(ctx, job, err)
+		return
+	}
+	completeReviewJob(ctx, job, &classification)
+}
+
+// processUserReviewJob stores the sentiment of a user review unless the
+// review was deleted or its text changed meanwhile.
+func processUserReviewJob(ctx context.Context, classifier llm.ReviewClassifier, job models.ReviewJob) {
+	if job.ReviewID == nil {
+		finishReviewJob(ctx, job, models.JobSuperseded, nil)
+		return
+	}
+	filter := bson.M{"_id": *job.ReviewID, "text": job.ReviewText}
+	if err := reviewCollection.FindOne(ctx, filter).Err(); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			finishReviewJob(ctx, job, models.JobSuperseded, nil)
+			return
+		}
+		failReviewJob(ctx, job, err)
+		return
+	}
+	movie, err := findReviewedMovie(ctx, job.ImdbID)
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+			finishReviewJob(ctx, job, models.JobSuperseded, nil)
+			return
+		}
+		failReviewJob(ctx, job, err)
+		return
+	}
+
+	jobCtx, cancel := context.WithTimeout(ctx, reviewJobTimeout)
+	classification, err := GetReviewRanking(jobCtx, classifier, movie, job.ReviewText)
+	cancel()
+	if err != nil {
+		failReviewJob(ctx, job, err)
+		return
+	}
+	update := bson.M{"$set": bson.M{
+		"sentiment":      classification.Ranking,
+		"classification": newReviewClassification(classification),
+	}}
+	result, err := reviewCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		failReviewJob(ctx, job, err)
+		return
+	}
+	status := models.JobSuperseded
+	if result.MatchedCount == 1 {
+		status = models.JobSucceeded
+	}
+	finishReviewJob(ctx, job, status, &classification)
+}
+
+// reviewJobMovieFilter matches the movie of job while it still points at the
+// job and still carries the review the job classifies, so that a job left
+// over from an earlier review never overwrites a newer ranking.
+func reviewJobMovieFilter(job models.ReviewJob) bson.M {
+	return bson.M{"imdb_id": job.ImdbID, "review_job_id": job.ID.Hex(), "admin_review": job.AdminReview}
+}
+
+// leasedJobFilter matches job only while the caller still holds its lease.
+func leasedJobFilter(job models.ReviewJob) bson.M {
+	return bson.M{"_id": job.ID, "status": models.JobRunning, "attempts": job.Attempts}
+}
+
+// completeReviewJob stores the classification on the movie unless a newer
+// review was submitted meanwhile, in which case, or when classification is
+// nil, the job is superseded.
+func completeReviewJob(ctx context.Context, job models.ReviewJob, classification *llm.Classification) {
+	status := models.JobSuperseded
+	if classification != nil {
+		movieUpdate := bson.M{
+			"$set": bson.M{
+				"ranking":        classification.Ranking,
+				"ranking_status": models.RankingRanked,
+				"classification": newReviewClassification(*classification),
+			},
+			"$inc": bson.M{"version": 1},
+		}
+		result, err := movieCollection.UpdateOne(ctx, reviewJobMovieFilter(job), movieUpdate)
+		if err != nil {
+			failReviewJob(ctx, job, err)
+			return
+		}
+		if result.MatchedCount == 1 {
+			status = models.JobSucceeded
+		}
+	}
+	finishReviewJob(ctx, job, status, classification)
+}
+
+// finishReviewJob moves a leased job to its final status and records the
+// classification it came up with, if any.
+func finishReviewJob(ctx context.Context, job models.ReviewJob, status string, classification *llm.Classification) {
+	now := time.Now()
+	set := bson.M{"status": status, "updated_at": now, "completed_at": now}
+	if classification != nil {
+		set["result"] = classification.Ranking
+		set["classification"] = newReviewClassification(*classification)
+	}
+	update := bson.M{"$set": set, "$unset": bson.M{"locked_until": "", "last_error": ""}}
+	if _, err := reviewJobCollection.UpdateOne(ctx, leasedJobFilter(job), update); err != nil {
+		log.Error().Err(err).Str("jobID", job.ID.Hex()).Msg("failed to complete review job")
+	}
+}
+
+// failReviewJob schedules another attempt with exponential backoff, or moves
+// the job to the dead state once its attempts are used up or the error is
+// permanent.
+func failReviewJob(ctx context.Context, job models.ReviewJob, cause error) {
+	now := time.Now()
+	event := log.Warn().Err(cause).Str("jobID", job.ID.Hex()).Str("imdbID", job.ImdbID).Int("attempt", job.Attempts)
+
+	set := bson.M{"last_error": cause.Error(), "updated_at": now}
+	if job.Attempts >= job.MaxAttempts || errors.Is(cause, llm.ErrNoRankings) {
+		set["status"] = models.JobDead
+		event.Msg("review job is dead")
+	} else {
+		set["status"] = models.JobQueued
+		set["run_at"] = now.Add(reviewJobBackoff(job.Attempts))
+		event.Msg("review job failed, retrying")
+	}
+	update := bson.M{"$set": set, "$unset": bson.M{"locked_until": ""}}
+	result, err := reviewJobCollection.UpdateOne(ctx, leasedJobFilter(job), update)
+	if err != nil {
+		log.Error().Err(err).Str("jobID", job.ID.Hex()).Msg("failed to record review job failure")
+		return
+	}
+	if result.MatchedCount == 1 && set["status"] == models.JobDead && job.Kind != models.ReviewJobUser {
+		_, err := movieCollection.UpdateOne(ctx,
+			reviewJobMovieFilter(job),
+			bson.M{"$set": bson.M{"ranking_status": models.RankingFailed}})
+		if err != nil {
+			log.Error().Err(err).Str("imdbID", job.ImdbID).Msg("failed to mark movie ranking as failed")
+		}
+	}
+}
+
+// reviewJobBackoff doubles the delay after every attempt, up to
+// reviewJobMaxBackoff, with up to 20% jitter so retries do not bunch up.
+func reviewJobBackoff(attempts int) time.Duration {
+	backoff := reviewJobMaxBackoff
+	if shift := attempts - 1; shift < 16 {
+		backoff = min(reviewJobBaseBackoff<<shift, reviewJobMaxBackoff)
+	}
+	return backoff + rand.N(backoff/5+1)
+}
@@ -11,42 +11,54 @@ import (
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/database"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/mailer"
 	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
 	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/utils"
 )
 
 var userCollection *mongo.Collection = database.OpenCollection("users")
 
-func RegisterUser() gin.HandlerFunc {
+// RegisterUser creates an account and emails a link to verify its address;
+// until then the account has restricted permissions.
+func RegisterUser(mail mailer.Mailer) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var user models.User
 
 		if err := c.ShouldBindJSON(&user); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+			respondError(c, errBadRequest("Invalid input data"))
 			return
 		}
+		// Roles are only granted by admins; everyone signs up as a USER.
+		user.Role = models.RoleUser
+		user.Disabled = false
+		user.EmailVerified = false
+		user.Token = ""
+		user.RefreshToken = ""
 		if err := validate.Struct(user); err != nil {
-			c.JSON(
-				http.StatusBadRequest,
-				gin.H{"error": "validation failed", "details": err.Error()},
-			)
+			respondError(c, errValidation(err))
 			return
 		}
 
 		hashedPassword, err := HashPassword(user.Password)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			respondError(c, errInternal("Failed to hash password", err))
+			return
 		}
 		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
+		if err := validateGenres(ctx, user.FavoriteGenres); err != nil {
+			respondError(c, err)
+			return
+		}
+
 		count, err := userCollection.CountDocuments(ctx, bson.M{"email": user.Email})
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing user"})
+			respondError(c, errInternal("Failed to check existing user", err))
 			return
 		}
 		if count > 0 {
-			c.JSON(http.StatusConflict, gin.H{"error": "User already exists"})
+			respondError(c, errConflict("User already exists"))
 			return
 		}
 		user.UserID = bson.NewObjectID().Hex()
@@ -55,9 +67,14 @@ func RegisterUser() gin.HandlerFunc {
 		user.Password = hashedPassword
 		result, err := userCollection.InsertOne(ctx, user)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+			if mongo.IsDuplicateKeyError(err) {
+				respondError(c, errConflict("User already exists"))
+				return
+			}
+			respondError(c, errInternal("Failed to create user", err))
 			return
 		}
+		go sendVerificationEmail(mail, user)
 		c.JSON(http.StatusCreated, result)
 	}
 }
@@ -67,34 +84,126 @@ func LoginUser() gin.HandlerFunc {
 		var userLogin models.UserLogin
 
 		if err := c.ShouldBindJSON(&userLogin); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+			respondError(c, errBadRequest("Invalid input data"))
 			return
 		}
 
 		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
+		attempt := newLoginAttempt(c, userLogin.Email)
+		if respondIfLockedOut(ctx, c, attempt) {
+			return
+		}
 		var foundUser models.User
 
 		err := userCollection.FindOne(ctx, bson.M{"email": userLogin.Email}).Decode(&foundUser)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+			// Spend the time of a real check so unknown emails do not stand out.
+			_ = bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(userLogin.Password))
+			recordLoginFailure(ctx, attempt)
+			respondError(c, errUnauthorized("Invalid email or password"))
 			return
 		}
 
 		err = bcrypt.CompareHashAndPassword([]byte(foundUser.Password), []byte(userLogin.Password))
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+			recordLoginFailure(ctx, attempt)
+			respondError(c, errUnauthorized("Invalid email or password"))
+			return
+		}
+		clearLoginFailures(ctx, attempt)
+		if foundUser.Disabled {
+			respondError(c, errForbidden("Account is disabled"))
 			return
 		}
 
-		token, refreshToken, err := utils.GenerateAllTokens(foundUser.Email, foundUser.FirstName, foundUser.LastName, foundUser.Role, foundUser.UserID)
+		token, refreshToken, err := utils.GenerateAllTokens(foundUser.Email, foundUser.FirstName, foundUser.LastName, foundUser.Role, foundUser.UserID, foundUser.TokenVersion, foundUser.EmailVerified)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate tokens"})
+			respondError(c, errInternal("Failed to generate tokens", err))
 			return
 		}
 		err = utils.UpdateAllTokens(foundUser.UserID, token, refreshToken)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tokens"})
+			respondError(c, errInternal("Failed to update tokens", err))
+			return
+		}
+
+		c.JSON(http.StatusOK, models.UserResponse{
+			UserID:         foundUser.UserID,
+			FirstName:      foundUser.FirstName,
+			LastName:       foundUser.LastName,
+			Email:          foundUser.Email,
+			Role:           foundUser.Role,
+			EmailVerified:  foundUser.EmailVerified,
+			Token:          token,
+			RefreshToken:   refreshToken,
+			FavoriteGenres: foundUser.FavoriteGenres,
+		})
+	}
+}
+
+func RefreshToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			RefreshToken string `json:"refresh_token" validate:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, errBadRequest("Invalid input data"))
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			respondError(c, errValidation(err))
+			return
+		}
+
+		claims, err := utils.ValidateRefreshToken(req.RefreshToken)
+		if err != nil {
+			respondError(c, errUnauthorized("Invalid refresh token"))
+			return
+		}
+		revoked, err := utils.IsTokenRevoked(claims)
+		if err != nil {
+			respondError(c, errInternal("Failed to verify refresh token", err))
+			return
+		}
+		if revoked {
+			respondError(c, errUnauthorized("Refresh token has been revoked"))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		var foundUser models.User
+
+		err = userCollection.FindOne(ctx, bson.M{"user_id": claims.UID}).Decode(&foundUser)
+		if err != nil {
+			respondError(c, errUnauthorized("Invalid refresh token"))
+			return
+		}
+		if foundUser.Disabled {
+			respondError(c, errForbidden("Account is disabled"))
+			return
+		}
+
+		token, refreshToken, err := utils.GenerateAllTokens(foundUser.Email, foundUser.FirstName, foundUser.LastName, foundUser.Role, foundUser.UserID, foundUser.TokenVersion, foundUser.EmailVerified)
+		if err != nil {
+			respondError(c, errInternal("Failed to generate tokens", err))
+			return
+		}
+		rotated, err := utils.RotateAllTokens(foundUser.UserID, req.RefreshToken, token, refreshToken)
+		if err != nil {
+			respondError(c, errInternal("Failed to update tokens", err))
+			return
+		}
+		if !rotated {
+			// The token is well signed but no longer the one on record, so it
+			// was already exchanged: treat it as stolen and end the session.
+			log.Warn().Str("userID", foundUser.UserID).Msg("refresh token reuse detected, revoking session")
+			if err := utils.RevokeAllTokens(foundUser.UserID); err != nil {
+				respondError(c, errInternal("Failed to revoke tokens", err))
+				return
+			}
+			respondError(c, errUnauthorized("Refresh token has already been used"))
 			return
 		}
 
@@ -104,6 +213,7 @@ func LoginUser() gin.HandlerFunc {
 			LastName:       foundUser.LastName,
 			Email:          foundUser.Email,
 			Role:           foundUser.Role,
+			EmailVerified:  foundUser.EmailVerified,
 			Token:          token,
 			RefreshToken:   refreshToken,
 			FavoriteGenres: foundUser.FavoriteGenres,
@@ -111,6 +221,64 @@ func LoginUser() gin.HandlerFunc {
 	}
 }
 
+func LogoutUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := utils.GetClaimsFromContext(c)
+		if err != nil {
+			respondError(c, errUnauthorized(err.Error()))
+			return
+		}
+		// The refresh token is optional; when given it is revoked as well.
+		var req struct {
+			RefreshToken string `json:"refresh_token"`
+		}
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				respondError(c, errBadRequest("Invalid input data"))
+				return
+			}
+		}
+
+		if err := utils.RevokeToken(claims); err != nil {
+			respondError(c, errInternal("Failed to revoke token", err))
+			return
+		}
+		if req.RefreshToken != "" {
+			refreshClaims, err := utils.ValidateRefreshToken(req.RefreshToken)
+			if err == nil && refreshClaims.UID == claims.UID {
+				if err := utils.RevokeToken(refreshClaims); err != nil {
+					respondError(c, errInternal("Failed to revoke refresh token", err))
+					return
+				}
+			}
+		}
+		token, err := utils.GetAccessToken(c)
+		if err == nil {
+			if err := utils.ClearStoredTokens(claims.UID, token); err != nil {
+				respondError(c, errInternal("Failed to update tokens", err))
+				return
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+	}
+}
+
+func LogoutAllDevices() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			respondError(c, errUnauthorized(err.Error()))
+			return
+		}
+		if err := utils.RevokeAllTokens(userID); err != nil {
+			respondError(c, errInternal("Failed to revoke tokens", err))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Logged out from all devices"})
+	}
+}
+
 func HashPassword(password string) (string, error) {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
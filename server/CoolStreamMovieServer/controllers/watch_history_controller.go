@@ -0,0 +1,198 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/database"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/utils"
+)
+
+var watchEventCollection *mongo.Collection = database.OpenCollection("watch_events")
+var watchProgressCollection *mongo.Collection = database.OpenCollection("watch_progress")
+
+// completedFraction is how much of a movie has to be played for it to count
+// as finished when the client does not say so, leaving room for credits.
+const completedFraction = 0.95
+
+// ReportWatchProgress records a playback progress report of the caller.
+// Positions and durations are in seconds.
+func ReportWatchProgress() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			respondError(c, errUnauthorized("Authentication required"))
+			return
+		}
+		var req struct {
+			ImdbID    string `json:"imdb_id"   validate:"required"`
+			Position  int64  `json:"position"  validate:"min=0"`
+			Duration  int64  `json:"duration"  validate:"required,gt=0"`
+			Completed bool   `json:"completed"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, errBadRequest("Invalid request body"))
+			return
+		}
+		req.ImdbID = strings.TrimSpace(req.ImdbID)
+		if err := validate.Struct(req); err != nil {
+			respondError(c, errValidation(err))
+			return
+		}
+		// Players report the position a little past the end now and then.
+		req.Position = min(req.Position, req.Duration)
+		completed := req.Completed || float64(req.Position) >= completedFraction*float64(req.Duration)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		count, err := movieCollection.CountDocuments(ctx, bson.M{"imdb_id": req.ImdbID})
+		if err != nil {
+			respondError(c, errInternal("Failed to fetch movie", err))
+			return
+		}
+		if count == 0 {
+			respondError(c, errNotFound("Movie not found"))
+			return
+		}
+
+		now := time.Now()
+		event := models.WatchEvent{
+			Meta:      models.WatchEventMeta{UserID: userID, ImdbID: req.ImdbID},
+			Position:  req.Position,
+			Duration:  req.Duration,
+			Completed: completed,
+			WatchedAt: now,
+		}
+		if _, err := watchEventCollection.InsertOne(ctx, event); err != nil {
+			respondError(c, errInternal("Failed to record watch event", err))
+			return
+		}
+
+		set := bson.M{
+			"position":   req.Position,
+			"duration":   req.Duration,
+			"completed":  completed,
+			"updated_at": now,
+		}
+		if completed {
+			set["completed_at"] = bson.M{"$ifNull": bson.A{"$completed_at", now}}
+		}
+		var progress models.WatchProgress
+		opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+		err = watchProgressCollection.FindOneAndUpdate(ctx,
+			bson.M{"user_id": userID, "imdb_id": req.ImdbID},
+			bson.A{bson.M{"$set": set}},
+			opts,
+		).Decode(&progress)
+		if err != nil {
+			respondError(c, errInternal("Failed to save watch progress", err))
+			return
+		}
+		c.JSON(http.StatusOK, progress)
+	}
+}
+
+// GetWatchHistory lists every movie the caller has played, most recently
+// watched first.
+func GetWatchHistory() gin.HandlerFunc {
+	return listWatchProgress(bson.M{})
+}
+
+// GetContinueWatching lists the movies the caller started but has not
+// finished, most recently watched first.
+func GetContinueWatching() gin.HandlerFunc {
+	return listWatchProgress(bson.M{"completed": false, "position": bson.M{"$gt": 0}})
+}
+
+func listWatchProgress(filter bson.M) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			respondError(c, errUnauthorized("Authentication required"))
+			return
+		}
+		page, limit, err := parsePagination(c)
+		if err != nil {
+			respondError(c, errBadRequest(err.Error()))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		userFilter := bson.M{"user_id": userID}
+		for key, value := range filter {
+			userFilter[key] = value
+		}
+		sort := bson.D{{Key: "updated_at", Value: -1}}
+		progress, total, err := findWithMovies[models.WatchProgress](ctx, watchProgressCollection, userFilter, sort, page, limit)
+		if err != nil {
+			respondError(c, errInternal("Failed to fetch watch history", err))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"history": progress, "meta": newPageMeta(page, limit, total)})
+	}
+}
+
+// finishedMovieIDs returns the imdb_ids of the movies the user has finished
+// at least once.
+func finishedMovieIDs(ctx context.Context, userID string) ([]string, error) {
+	filter := bson.M{"user_id": userID, "completed_at": bson.M{"$exists": true}}
+	opts := options.Find().SetProjection(bson.M{"imdb_id": 1})
+	cursor, err := watchProgressCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var finished []models.WatchProgress
+	if err := cursor.All(ctx, &finished); err != nil {
+		return nil, err
+	}
+	imdbIDs := make([]string, 0, len(finished))
+	for _, progress := range finished {
+		imdbIDs = append(imdbIDs, progress.ImdbID)
+	}
+	return imdbIDs, nil
+}
+
+// findWithMovies returns one page of the documents of collection matching
+// filter, each with its movie looked up into the movie field, and the total
+// number of matches.
+func findWithMovies[T any](ctx context.Context, collection *mongo.Collection, filter bson.M, sort bson.D, page, limit int64) ([]T, int64, error) {
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	pipeline := bson.A{
+		bson.M{"$match": filter},
+		bson.M{"$sort": sort},
+		bson.M{"$skip": (page - 1) * limit},
+		bson.M{"$limit": limit},
+		bson.M{"$lookup": bson.M{
+			"from":         "movies",
+			"localField":   "imdb_id",
+			"foreignField": "imdb_id",
+			"as":           "movie",
+		}},
+		bson.M{"$unwind": bson.M{"path": "$movie", "preserveNullAndEmptyArrays": true}},
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	results := []T{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, 0, err
+	}
+	return results, total, nil
+}
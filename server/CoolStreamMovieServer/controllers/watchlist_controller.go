@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/database"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/utils"
+)
+
+var watchlistCollection *mongo.Collection = database.OpenCollection("watchlist")
+
+// GetWatchlist lists the caller's watchlist in their order, each entry with
+// the details of its movie.
+func GetWatchlist() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			respondError(c, errUnauthorized("Authentication required"))
+			return
+		}
+		page, limit, err := parsePagination(c)
+		if err != nil {
+			respondError(c, errBadRequest(err.Error()))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		filter := bson.M{"user_id": userID}
+		sort := bson.D{{Key: "position", Value: 1}, {Key: "added_at", Value: 1}}
+		entries, total, err := findWithMovies[models.WatchlistEntry](ctx, watchlistCollection, filter, sort, page, limit)
+		if err != nil {
+			respondError(c, errInternal("Failed to fetch watchlist", err))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"watchlist": entries, "meta": newPageMeta(page, limit, total)})
+	}
+}
+
+// AddToWatchlist puts a movie at the end of the caller's watchlist.
+func AddToWatchlist() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			respondError(c, errUnauthorized("Authentication required"))
+			return
+		}
+		var req struct {
+			ImdbID string `json:"imdb_id" validate:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, errBadRequest("Invalid request body"))
+			return
+		}
+		req.ImdbID = strings.TrimSpace(req.ImdbID)
+		if err := validate.Struct(req); err != nil {
+			respondError(c, errValidation(err))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		count, err := movieCollection.CountDocuments(ctx, bson.M{"imdb_id": req.ImdbID})
+		if err != nil {
+			respondError(c, errInternal("Failed to fetch movie", err))
+			return
+		}
+		if count == 0 {
+			respondError(c, errNotFound("Movie not found"))
+			return
+		}
+		position, err := nextWatchlistPosition(ctx, userID)
+		if err != nil {
+			respondError(c, errInternal("Failed to fetch watchlist", err))
+			return
+		}
+		entry := models.WatchlistEntry{
+			UserID:   userID,
+			ImdbID:   req.ImdbID,
+			Position: position,
+			AddedAt:  time.Now(),
+		}
+		if _, err := watchlistCollection.InsertOne(ctx, entry); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				respondError(c, errConflict("Movie is already on your watchlist"))
+				return
+			}
+			respondError(c, errInternal("Failed to add to watchlist", err))
+			return
+		}
+		c.JSON(http.StatusCreated, entry)
+	}
+}
+
+// RemoveFromWatchlist takes a movie off the caller's watchlist. Removing a
+// movie that is not on it succeeds as well.
+func RemoveFromWatchlist() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			respondError(c, errUnauthorized("Authentication required"))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		filter := bson.M{"user_id": userID, "imdb_id": c.Param("imdb_id")}
+		if _, err := watchlistCollection.DeleteOne(ctx, filter); err != nil {
+			respondError(c, errInternal("Failed to remove from watchlist", err))
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// ReorderWatchlist puts the caller's watchlist in the order of imdb_ids,
+// which must name every entry exactly once.
+func ReorderWatchlist() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			respondError(c, errUnauthorized("Authentication required"))
+			return
+		}
+		var req struct {
+			ImdbIDs []string `json:"imdb_ids" validate:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, errBadRequest("Invalid request body"))
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			respondError(c, errValidation(err))
+			return
+		}
+		requested := make(map[string]bool, len(req.ImdbIDs))
+		for _, imdbID := range req.ImdbIDs {
+			if requested[imdbID] {
+				respondError(c, errBadRequest("imdb_ids lists "+imdbID+" more than once"))
+				return
+			}
+			requested[imdbID] = true
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		opts := options.Find().SetProjection(bson.M{"imdb_id": 1})
+		cursor, err := watchlistCollection.Find(ctx, bson.M{"user_id": userID}, opts)
+		if err != nil {
+			respondError(c, errInternal("Failed to fetch watchlist", err))
+			return
+		}
+		var current []models.WatchlistEntry
+		if err := cursor.All(ctx, &current); err != nil {
+			respondError(c, errInternal("Failed to decode watchlist", err))
+			return
+		}
+		if len(current) != len(requested) {
+			respondError(c, errConflict("imdb_ids must list every movie on your watchlist"))
+			return
+		}
+		for _, entry := range current {
+			if !requested[entry.ImdbID] {
+				respondError(c, errConflict("imdb_ids must list every movie on your watchlist"))
+				return
+			}
+		}
+		if len(current) == 0 {
+			c.Status(http.StatusNoContent)
+			return
+		}
+
+		writes := make([]mongo.WriteModel, 0, len(req.ImdbIDs))
+		for position, imdbID := range req.ImdbIDs {
+			writes = append(writes, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"user_id": userID, "imdb_id": imdbID}).
+				SetUpdate(bson.M{"$set": bson.M{"position": int64(position)}}))
+		}
+		if _, err := watchlistCollection.BulkWrite(ctx, writes); err != nil {
+			respondError(c, errInternal("Failed to reorder watchlist", err))
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// nextWatchlistPosition is the position after the last entry of the user's
+// watchlist.
+func nextWatchlistPosition(ctx context.Context, userID string) (int64, error) {
+	var last models.WatchlistEntry
+	opts := options.FindOne().
+		SetSort(bson.D{{Key: "position", Value: -1}}).
+		SetProjection(bson.M{"position": 1})
+	err := watchlistCollection.FindOne(ctx, bson.M{"user_id": userID}, opts).Decode(&last)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return last.Position + 1, nil
+}
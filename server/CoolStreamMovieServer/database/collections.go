@@ -0,0 +1,71 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// namespaceExists is the server error code for creating a collection that
+// already exists.
+const namespaceExists = 48
+
+// timeSeriesCollection is a time series collection whose documents are
+// removed retention after their time field; retentionEnv, a number of days,
+// overrides the default.
+type timeSeriesCollection struct {
+	options      *options.TimeSeriesOptionsBuilder
+	retention    time.Duration
+	retentionEnv string
+}
+
+var timeSeriesCollections = map[string]timeSeriesCollection{
+	"watch_events": {
+		options: options.TimeSeries().
+			SetTimeField("watched_at").
+			SetMetaField("meta").
+			SetGranularity("minutes"),
+		retention:    90 * 24 * time.Hour,
+		retentionEnv: "WATCH_EVENT_RETENTION_DAYS",
+	},
+}
+
+// EnsureCollections creates the collections that need options at creation,
+// such as time series. It must run before EnsureIndexes, which would
+// otherwise create them as plain collections. Existing time series keep
+// their options but get the configured retention.
+func EnsureCollections(ctx context.Context) error {
+	var errs []error
+	for collectionName, timeSeries := range timeSeriesCollections {
+		db := OpenCollection(collectionName).Database()
+		expireAfter := int64(timeSeries.retentionPeriod().Seconds())
+		opts := options.CreateCollection().
+			SetTimeSeriesOptions(timeSeries.options).
+			SetExpireAfterSeconds(expireAfter)
+		err := db.CreateCollection(ctx, collectionName, opts)
+		var cmdErr mongo.CommandError
+		if errors.As(err, &cmdErr) && cmdErr.Code == namespaceExists {
+			command := bson.D{{Key: "collMod", Value: collectionName}, {Key: "expireAfterSeconds", Value: expireAfter}}
+			err = db.RunCommand(ctx, command).Err()
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", collectionName, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c timeSeriesCollection) retentionPeriod() time.Duration {
+	days, err := strconv.Atoi(os.Getenv(c.retentionEnv))
+	if err != nil || days < 1 {
+		return c.retention
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
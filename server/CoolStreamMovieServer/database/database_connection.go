@@ -4,6 +4,7 @@ import (
 	"log"
 	"log/slog"
 	"os"
+	"sync"
 
 	"github.com/joho/godotenv"
 	"go.mongodb.org/mongo-driver/v2/mongo"
@@ -31,7 +32,9 @@ func DBInstance() *mongo.Client {
 	return client
 }
 
-var Client *mongo.Client = DBInstance()
+// client connects on first use rather than on import, so that packages
+// which merely import this one, and their tests, need no database.
+var client = sync.OnceValue(DBInstance)
 
 func OpenCollection(collectioName string) *mongo.Collection {
 	err := godotenv.Load(".env")
@@ -46,9 +49,17 @@ func OpenCollection(collectioName string) *mongo.Collection {
 	}
 	slog.Info("DATABASE_NAME", "value", databaseName)
 
-	collection := Client.Database(databaseName).Collection(collectioName)
+	collection := client().Database(databaseName).Collection(collectioName)
 	if collection == nil {
 		return nil
 	}
 	return collection
 }
+
+// LazyCollection returns a getter that opens the collection on its first
+// call.
+func LazyCollection(collectionName string) func() *mongo.Collection {
+	return sync.OnceValue(func() *mongo.Collection {
+		return OpenCollection(collectionName)
+	})
+}
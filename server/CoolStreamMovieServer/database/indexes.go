@@ -0,0 +1,145 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var collectionIndexes = map[string][]mongo.IndexModel{
+	"action_tokens": {
+		{Keys: bson.D{{Key: "jti", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "purpose", Value: 1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	},
+	"audit_logs": {
+		{Keys: bson.D{{Key: "target_user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "actor_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	},
+	"login_attempts": {
+		{Keys: bson.D{{Key: "key", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	},
+	"movies": {
+		{Keys: bson.D{{Key: "imdb_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "title", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "ranking.ranking_value", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "genre.genre_name", Value: 1}}},
+		{Keys: bson.D{{Key: "genre.genre_id", Value: 1}}},
+		{Keys: bson.D{{Key: "title_ngrams", Value: 1}}},
+		{
+			Keys: bson.D{
+				{Key: "title", Value: "text"},
+				{Key: "admin_review", Value: "text"},
+				{Key: "genre.genre_name", Value: "text"},
+			},
+			Options: options.Index().
+				SetName("movie_text").
+				SetWeights(bson.D{
+					{Key: "title", Value: 10},
+					{Key: "genre.genre_name", Value: 3},
+					{Key: "admin_review", Value: 1},
+				}),
+		},
+	},
+	"password_reset_requests": {
+		{Keys: bson.D{{Key: "key", Value: 1}, {Key: "expires_at", Value: 1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	},
+	"rankings": {
+		{Keys: bson.D{{Key: "ranking_value", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "ranking_name", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{
+			Keys: bson.D{{Key: "unranked", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"unranked": true}),
+		},
+	},
+	"users": {
+		{Keys: bson.D{{Key: "email", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "favourite_genres.genre_id", Value: 1}}},
+	},
+	"classification_cache": {
+		{Keys: bson.D{{Key: "created_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(30 * 24 * 60 * 60)},
+	},
+	"genres": {
+		{Keys: bson.D{{Key: "genre_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{
+			Keys: bson.D{{Key: "genre_name", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetCollation(&options.Collation{Locale: "en", Strength: 2}),
+		},
+	},
+	"review_jobs": {
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "run_at", Value: 1}}},
+		{Keys: bson.D{{Key: "imdb_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		// Finished jobs are kept for a week; dead jobs have no completed_at
+		// and stay until they are retried.
+		{Keys: bson.D{{Key: "completed_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(7 * 24 * 60 * 60)},
+	},
+	"prompt_templates": {
+		{Keys: bson.D{{Key: "version", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{
+			Keys: bson.D{{Key: "active", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"active": true}),
+		},
+	},
+	"rerank_runs": {
+		{Keys: bson.D{{Key: "started_at", Value: -1}}},
+		// At most one run is running at a time.
+		{
+			Keys: bson.D{{Key: "status", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"status": "running"}),
+		},
+	},
+	"rerank_diffs": {
+		{Keys: bson.D{{Key: "run_id", Value: 1}, {Key: "imdb_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+	},
+	"reviews": {
+		{Keys: bson.D{{Key: "imdb_id", Value: 1}, {Key: "user_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "imdb_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+	},
+	"watch_events": {
+		{Keys: bson.D{{Key: "meta.user_id", Value: 1}, {Key: "meta.imdb_id", Value: 1}, {Key: "watched_at", Value: -1}}},
+	},
+	"watch_progress": {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "imdb_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "updated_at", Value: -1}}},
+	},
+	"watchlist": {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "imdb_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "position", Value: 1}}},
+	},
+	"revoked_tokens": {
+		{Keys: bson.D{{Key: "jti", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	},
+}
+
+// EnsureIndexes creates the indexes the application relies on. Creating an
+// index that already exists is a no-op, so it is safe to call on every start.
+// A collection whose indexes cannot be built, for example because existing
+// documents violate a unique index, does not stop the others.
+func EnsureIndexes(ctx context.Context) error {
+	var errs []error
+	for collectionName, indexes := range collectionIndexes {
+		collection := OpenCollection(collectionName)
+		if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", collectionName, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,110 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/cache"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/textutil"
+)
+
+// ClassificationStore persists classifications under the keys built by
+// CacheKey.
+type ClassificationStore interface {
+	Load(ctx context.Context, key string) (Classification, bool, error)
+	Save(ctx context.Context, key string, classification Classification) error
+}
+
+// CachingClassifier answers repeated classifications from an in-memory LRU
+// backed by a ClassificationStore, and only asks the wrapped classifier on a
+// miss. Answers from a fallback model are not cached, so the review is asked
+// again once the primary model is back.
+type CachingClassifier struct {
+	inner  ReviewClassifier
+	memory *cache.LRU[string, Classification]
+	store  ClassificationStore
+}
+
+// NewCachingClassifier caches up to capacity classifications in memory.
+// store may be nil to cache in memory only.
+func NewCachingClassifier(inner ReviewClassifier, store ClassificationStore, capacity int) *CachingClassifier {
+	return &CachingClassifier{inner: inner, memory: cache.NewLRU[string, Classification](capacity), store: store}
+}
+
+func (c *CachingClassifier) Model() string {
+	return c.inner.Model()
+}
+
+func (c *CachingClassifier) Classify(ctx context.Context, req ClassifyRequest) (Classification, error) {
+	model := c.inner.Model()
+	// A request without a stored template is prompted with the inner
+	// classifier's default, so the key must change when that default does.
+	keyReq := req
+	if keyReq.Template == "" {
+		keyReq.Template = defaultTemplate(c.inner)
+	}
+	key := CacheKey(model, keyReq)
+	if classification, ok := c.memory.Get(key); ok {
+		return classification, nil
+	}
+	if c.store != nil {
+		classification, ok, err := c.store.Load(ctx, key)
+		if err != nil {
+			log.Warn().Err(err).Msg("failed to load cached classification")
+		} else if ok {
+			c.memory.Set(key, classification)
+			return classification, nil
+		}
+	}
+
+	classification, err := c.inner.Classify(ctx, req)
+	if err != nil || classification.Model != model {
+		return classification, err
+	}
+	c.memory.Set(key, classification)
+	if c.store != nil {
+		if err := c.store.Save(ctx, key, classification); err != nil {
+			log.Warn().Err(err).Msg("failed to store cached classification")
+		}
+	}
+	return classification, nil
+}
+
+// CacheKey is the SHA-256 of everything that decides a classification: the
+// prompt version and text, the model, the ranking scale and the normalized
+// review. The title and genres only count when the prompt uses them.
+func CacheKey(model string, req ClassifyRequest) string {
+	h := sha256.New()
+	writeKeyPart(h, strconv.Itoa(req.PromptVersion))
+	writeKeyPart(h, req.Template)
+	writeKeyPart(h, model)
+
+	rankings := classifiable(req.Rankings)
+	slices.SortFunc(rankings, func(a, b models.Ranking) int { return a.RankingValue - b.RankingValue })
+	for _, ranking := range rankings {
+		writeKeyPart(h, strconv.Itoa(ranking.RankingValue))
+		writeKeyPart(h, ranking.RankingName)
+	}
+
+	if strings.Contains(req.Template, "{"+VarTitle+"}") {
+		writeKeyPart(h, req.Title)
+	}
+	if strings.Contains(req.Template, "{"+VarGenres+"}") {
+		writeKeyPart(h, strings.Join(req.Genres, ","))
+	}
+	writeKeyPart(h, textutil.Normalize(req.Review))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeKeyPart writes s followed by a separator, so that parts cannot run
+// into each other.
+func writeKeyPart(h hash.Hash, s string) {
+	h.Write([]byte(s))
+	h.Write([]byte{0})
+}
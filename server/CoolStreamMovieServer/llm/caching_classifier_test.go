@@ -0,0 +1,43 @@
+package llm
+
+import (
+	"context"
+	"testing"
+)
+
+// mapStore is a ClassificationStore in a map, standing in for Mongo.
+type mapStore map[string]Classification
+
+func (s mapStore) Load(_ context.Context, key string) (Classification, bool, error) {
+	classification, ok := s[key]
+	return classification, ok, nil
+}
+
+func (s mapStore) Save(_ context.Context, key string, classification Classification) error {
+	s[key] = classification
+	return nil
+}
+
+func TestCachingClassifierDefaultTemplate(t *testing.T) {
+	store := mapStore{}
+	req := ClassifyRequest{Review: "A fine film.", Rankings: testRankings}
+	classify := func(template string) *fakeProvider {
+		t.Helper()
+		provider := &fakeProvider{responses: []string{`{"ranking": "Good"}`}}
+		classifier := NewCachingClassifier(NewLLMClassifier(provider, template), store, 10)
+		if _, err := classifier.Classify(context.Background(), req); err != nil {
+			t.Fatalf("Classify: %v", err)
+		}
+		return provider
+	}
+
+	if provider := classify("Rate {review} as one of {rankings}."); provider.calls != 1 {
+		t.Fatalf("first classification made %d calls, want 1", provider.calls)
+	}
+	if provider := classify("Rate {review} as one of {rankings}."); provider.calls != 0 {
+		t.Errorf("same template made %d calls, want a cache hit", provider.calls)
+	}
+	if provider := classify("Judge {review}, answering one of {rankings}."); provider.calls != 1 {
+		t.Errorf("changed template made %d calls, want a cache miss", provider.calls)
+	}
+}
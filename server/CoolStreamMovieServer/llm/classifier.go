@@ -0,0 +1,167 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/logger"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+)
+
+var log = logger.GetLogger()
+
+var (
+	// ErrNoRankings is returned when the scale offers nothing to classify into.
+	ErrNoRankings = errors.New("no rankings to classify into")
+	// ErrUnparseableResponse is returned when the model keeps answering with
+	// something that is not one of the rankings.
+	ErrUnparseableResponse = errors.New("unparseable classification response")
+)
+
+// maxClassifyAttempts bounds the calls LLMClassifier makes for one review,
+// the first one included.
+const maxClassifyAttempts = 3
+
+// ClassifyRequest is a review to classify with the context a prompt may use.
+type ClassifyRequest struct {
+	Review   string
+	Title    string
+	Genres   []string
+	Rankings []models.Ranking
+	// Template and PromptVersion select a stored prompt template. An empty
+	// Template leaves the classifier on its default prompt.
+	Template      string
+	PromptVersion int
+}
+
+// Classification is the ranking chosen for a review and the model and prompt
+// version that chose it. PromptVersion is 0 when no stored prompt was used.
+type Classification struct {
+	Ranking       models.Ranking
+	Model         string
+	PromptVersion int
+}
+
+// ReviewClassifier picks the ranking from req.Rankings that best matches a
+// review. The unranked sentinel is never a valid answer.
+type ReviewClassifier interface {
+	Classify(ctx context.Context, req ClassifyRequest) (Classification, error)
+	// Model names the model classifications normally come from.
+	Model() string
+}
+
+// LLMClassifier asks a Provider to name the ranking, using the request's
+// template or else promptTemplate; see RenderPrompt for the variables. The
+// model is asked for JSON and its answer is matched leniently against the
+// ranking names; answers that match nothing are retried with a corrective
+// prompt.
+type LLMClassifier struct {
+	provider       Provider
+	promptTemplate string
+}
+
+func NewLLMClassifier(provider Provider, promptTemplate string) *LLMClassifier {
+	return &LLMClassifier{provider: provider, promptTemplate: promptTemplate}
+}
+
+func (c *LLMClassifier) Model() string {
+	return c.provider.Model()
+}
+
+// DefaultTemplate is the prompt template used for requests without one.
+func (c *LLMClassifier) DefaultTemplate() string {
+	return c.promptTemplate
+}
+
+func (c *LLMClassifier) Classify(ctx context.Context, req ClassifyRequest) (Classification, error) {
+	candidates := classifiable(req.Rankings)
+	if len(candidates) == 0 {
+		return Classification{}, ErrNoRankings
+	}
+	names := make([]string, 0, len(candidates))
+	for _, ranking := range candidates {
+		names = append(names, ranking.RankingName)
+	}
+	template, promptVersion := c.promptTemplate, 0
+	if req.Template != "" {
+		template, promptVersion = req.Template, req.PromptVersion
+	}
+	rankingList := strings.Join(names, ",")
+	prompt := BuildClassificationPrompt(template, PromptData{
+		Rankings: names,
+		Title:    req.Title,
+		Genres:   req.Genres,
+		Review:   req.Review,
+	})
+
+	var response string
+	for attempt := 1; attempt <= maxClassifyAttempts; attempt++ {
+		var err error
+		response, err = c.provider.Generate(ctx, prompt, WithJSON())
+		if err != nil {
+			return Classification{}, err
+		}
+		if ranking, ok := matchRanking(extractAnswer(response), candidates); ok {
+			return Classification{Ranking: ranking, Model: c.provider.Model(), PromptVersion: promptVersion}, nil
+		}
+		log.Warn().Int("attempt", attempt).Str("response", response).Msg("llm response matched no ranking")
+		prompt += fmt.Sprintf("\n\nYour previous answer %q is not one of the allowed rankings. "+
+			"Answer again with only {\"ranking\": \"<name>\"}, using one of these names exactly: %s.", response, rankingList)
+	}
+	return Classification{}, fmt.Errorf("%w after %d attempts, last response %q", ErrUnparseableResponse, maxClassifyAttempts, response)
+}
+
+// FallbackClassifier uses Primary and turns to Fallback when Primary fails,
+// for instance because the LLM endpoint is unreachable. An unparseable answer
+// is passed on instead, since the model did respond and guessing would hide
+// the problem.
+type FallbackClassifier struct {
+	Primary  ReviewClassifier
+	Fallback ReviewClassifier
+}
+
+func (c *FallbackClassifier) Model() string {
+	return c.Primary.Model()
+}
+
+// DefaultTemplate is the default prompt template of Primary.
+func (c *FallbackClassifier) DefaultTemplate() string {
+	return defaultTemplate(c.Primary)
+}
+
+func (c *FallbackClassifier) Classify(ctx context.Context, req ClassifyRequest) (Classification, error) {
+	classification, err := c.Primary.Classify(ctx, req)
+	if err == nil || errors.Is(err, ErrNoRankings) || errors.Is(err, ErrUnparseableResponse) {
+		return classification, err
+	}
+	log.Warn().Err(err).Msg("review classifier failed, using fallback")
+	return c.Fallback.Classify(ctx, req)
+}
+
+// templatedClassifier is implemented by classifiers that prompt a model with
+// a default template when a request carries none.
+type templatedClassifier interface {
+	DefaultTemplate() string
+}
+
+// defaultTemplate returns the default prompt template of classifier, or ""
+// when it does not use one.
+func defaultTemplate(classifier ReviewClassifier) string {
+	if templated, ok := classifier.(templatedClassifier); ok {
+		return templated.DefaultTemplate()
+	}
+	return ""
+}
+
+// classifiable drops the unranked sentinel from rankings.
+func classifiable(rankings []models.Ranking) []models.Ranking {
+	candidates := make([]models.Ranking, 0, len(rankings))
+	for _, ranking := range rankings {
+		if !ranking.Unranked {
+			candidates = append(candidates, ranking)
+		}
+	}
+	return candidates
+}
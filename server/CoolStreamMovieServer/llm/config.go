@@ -0,0 +1,58 @@
+package llm
+
+import (
+	"fmt"
+	"os"
+)
+
+const (
+	ProviderOpenAI           = "openai"
+	ProviderOpenAICompatible = "openai-compatible"
+	ProviderRules            = "rules"
+)
+
+// NewProviderFromEnv builds the provider selected by LLM_PROVIDER:
+//
+//   - openai (default): OPENAI_API_KEY and optionally LLM_MODEL; when
+//     LLM_PROVIDER is unset and there is no key no LLM is used
+//   - openai-compatible: LLM_BASE_URL, optionally LLM_API_KEY and LLM_MODEL
+//   - rules: no LLM
+//
+// It returns a nil Provider when no LLM is configured.
+func NewProviderFromEnv() (Provider, error) {
+	switch name := os.Getenv("LLM_PROVIDER"); name {
+	case "":
+		if os.Getenv("OPENAI_API_KEY") == "" {
+			log.Warn().Msg("OPENAI_API_KEY is not set, running without an LLM")
+			return nil, nil
+		}
+		fallthrough
+	case ProviderOpenAI:
+		return NewOpenAIProvider(os.Getenv("OPENAI_API_KEY"), os.Getenv("LLM_MODEL"))
+	case ProviderOpenAICompatible:
+		baseURL := os.Getenv("LLM_BASE_URL")
+		if baseURL == "" {
+			return nil, fmt.Errorf("LLM_BASE_URL is required for the %s provider", name)
+		}
+		return NewOpenAICompatibleProvider(baseURL, os.Getenv("LLM_API_KEY"), os.Getenv("LLM_MODEL"))
+	case ProviderRules:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown LLM_PROVIDER %q", name)
+	}
+}
+
+// NewReviewClassifier classifies with provider, or with the RuleClassifier
+// when provider is nil. An LLM falls back to the RuleClassifier when it
+// fails, unless LLM_FALLBACK is set to "none". BASE_PROMPT_TEMPLATE is the
+// prompt used while a request carries no stored template.
+func NewReviewClassifier(provider Provider) ReviewClassifier {
+	if provider == nil {
+		return NewRuleClassifier()
+	}
+	classifier := NewLLMClassifier(provider, os.Getenv("BASE_PROMPT_TEMPLATE"))
+	if os.Getenv("LLM_FALLBACK") == "none" {
+		return classifier
+	}
+	return &FallbackClassifier{Primary: classifier, Fallback: NewRuleClassifier()}
+}
@@ -0,0 +1,72 @@
+package llm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Prompt template variables.
+const (
+	VarRankings = "rankings"
+	VarTitle    = "title"
+	VarGenres   = "genres"
+	VarReview   = "review"
+)
+
+var (
+	promptVariables = []string{VarRankings, VarTitle, VarGenres, VarReview}
+	placeholderRe   = regexp.MustCompile(`\{([a-z_]+)\}`)
+)
+
+// PromptData fills the variables of a prompt template.
+type PromptData struct {
+	Rankings []string
+	Title    string
+	Genres   []string
+	Review   string
+}
+
+// ValidatePromptTemplate rejects templates using placeholders other than
+// {rankings}, {title}, {genres} and {review}. JSON examples such as
+// {"ranking": "Good"} are not placeholders.
+func ValidatePromptTemplate(template string) error {
+	if strings.TrimSpace(template) == "" {
+		return fmt.Errorf("template is empty")
+	}
+	for _, match := range placeholderRe.FindAllStringSubmatch(template, -1) {
+		known := false
+		for _, variable := range promptVariables {
+			known = known || match[1] == variable
+		}
+		if !known {
+			return fmt.Errorf("unknown variable {%s}, expected one of {%s}", match[1], strings.Join(promptVariables, "}, {"))
+		}
+	}
+	return nil
+}
+
+// RenderPrompt substitutes the variables of template. Templates without a
+// {review} placeholder get the review appended, the way the original
+// BASE_PROMPT_TEMPLATE was used.
+func RenderPrompt(template string, data PromptData) string {
+	replacer := strings.NewReplacer(
+		"{"+VarRankings+"}", strings.Join(data.Rankings, ","),
+		"{"+VarTitle+"}", data.Title,
+		"{"+VarGenres+"}", strings.Join(data.Genres, ", "),
+		"{"+VarReview+"}", data.Review,
+	)
+	prompt := replacer.Replace(template)
+	if !strings.Contains(template, "{"+VarReview+"}") {
+		prompt += data.Review
+	}
+	return prompt
+}
+
+// BuildClassificationPrompt renders template and appends the output format
+// instructions LLMClassifier relies on.
+func BuildClassificationPrompt(template string, data PromptData) string {
+	return RenderPrompt(template, data) +
+		"\n\nRespond only with a JSON object of the form {\"ranking\": \"<name>\"} where <name> is one of: " +
+		strings.Join(data.Rankings, ",") + "."
+}
@@ -0,0 +1,94 @@
+package llm
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/textutil"
+)
+
+// rankingAnswer is the JSON object the model is asked to reply with.
+type rankingAnswer struct {
+	Ranking string `json:"ranking"`
+}
+
+// extractAnswer returns the ranking named in a model response. It prefers
+// the "ranking" field of the first JSON object in the response, which also
+// copes with objects wrapped in code fences or prose, and falls back to the
+// whole response.
+func extractAnswer(response string) string {
+	start, end := strings.Index(response, "{"), strings.LastIndex(response, "}")
+	if start >= 0 && end > start {
+		var answer rankingAnswer
+		if err := json.Unmarshal([]byte(response[start:end+1]), &answer); err == nil && answer.Ranking != "" {
+			return answer.Ranking
+		}
+	}
+	return response
+}
+
+// matchRanking maps a free-form answer onto one of the candidate rankings,
+// ignoring case, punctuation and whitespace. In order it accepts an exact
+// match, a sentence that mentions exactly one ranking name, and a name
+// misspelt by at most textutil.MaxEdits. Ambiguous answers do not match.
+func matchRanking(answer string, candidates []models.Ranking) (models.Ranking, bool) {
+	normalized := textutil.Normalize(answer)
+	if normalized == "" {
+		return models.Ranking{}, false
+	}
+	names := make([]string, len(candidates))
+	for i, ranking := range candidates {
+		names[i] = textutil.Normalize(ranking.RankingName)
+		if names[i] == normalized {
+			return ranking, true
+		}
+	}
+
+	// A name mentioned in a sentence, such as "The sentiment is Good.". Names
+	// contained in another mentioned name ("Good" in "Very Good") yield to it.
+	var mentioned []int
+	padded := " " + normalized + " "
+	for i, name := range names {
+		if name != "" && strings.Contains(padded, " "+name+" ") {
+			mentioned = append(mentioned, i)
+		}
+	}
+	var outermost []int
+	for _, i := range mentioned {
+		contained := false
+		for _, j := range mentioned {
+			if i != j && names[i] != names[j] && strings.Contains(" "+names[j]+" ", " "+names[i]+" ") {
+				contained = true
+				break
+			}
+		}
+		if !contained {
+			outermost = append(outermost, i)
+		}
+	}
+	if len(outermost) == 1 {
+		return candidates[outermost[0]], true
+	}
+	if len(outermost) > 1 {
+		return models.Ranking{}, false
+	}
+
+	best, bestDistance, tied := -1, 0, false
+	for i, name := range names {
+		distance := textutil.Levenshtein(normalized, name)
+		if distance > textutil.MaxEdits(name) {
+			continue
+		}
+		switch {
+		case best < 0 || distance < bestDistance:
+			best, bestDistance, tied = i, distance, false
+		case distance == bestDistance:
+			tied = true
+		}
+	}
+	if best < 0 || tied {
+		return models.Ranking{}, false
+	}
+	return candidates[best], true
+}
@@ -0,0 +1,86 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestExtractAnswer(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     string
+	}{
+		{"json", `{"ranking": "Good"}`, "Good"},
+		{"fenced json", "```json\n{\"ranking\": \"Bad\"}\n```", "Bad"},
+		{"json in prose", `Sure! {"ranking":"Excellent"} Hope that helps.`, "Excellent"},
+		{"bare name", "Okay", "Okay"},
+		{"json without ranking", `{"answer": "Good"}`, `{"answer": "Good"}`},
+		{"broken json", `{"ranking": "Good"`, `{"ranking": "Good"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractAnswer(tt.response); got != tt.want {
+				t.Errorf("extractAnswer(%q) = %q, want %q", tt.response, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchRanking(t *testing.T) {
+	candidates := classifiable(testRankings)
+	tests := []struct {
+		name   string
+		answer string
+		want   string
+		ok     bool
+	}{
+		{"exact", "Good", "Good", true},
+		{"lower case", "terrible", "Terrible", true},
+		{"upper case with punctuation", "EXCELLENT!", "Excellent", true},
+		{"quoted with whitespace", "  \"Okay.\"  ", "Okay", true},
+		{"in a sentence", "The sentiment of this review is Bad.", "Bad", true},
+		{"misspelt", "Excelent", "Excellent", true},
+		{"two names", "Good or Bad", "", false},
+		{"unranked sentinel", "Not_Ranked", "", false},
+		{"no match", "Mediocre", "", false},
+		{"empty", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := matchRanking(tt.answer, candidates)
+			if ok != tt.ok {
+				t.Fatalf("matchRanking(%q) ok = %v, want %v", tt.answer, ok, tt.ok)
+			}
+			if ok && got.RankingName != tt.want {
+				t.Errorf("matchRanking(%q) = %q, want %q", tt.answer, got.RankingName, tt.want)
+			}
+		})
+	}
+}
+
+func TestLLMClassifierRetries(t *testing.T) {
+	req := ClassifyRequest{Review: "A wonderful film.", Rankings: testRankings}
+
+	provider := &fakeProvider{responses: []string{"Splendid", "```json\n{\"ranking\": \"good\"}\n```"}}
+	got, err := NewLLMClassifier(provider, "{review}").Classify(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Classify: %v", err)
+	}
+	if got.Ranking.RankingName != "Good" || got.Model != "fake" {
+		t.Errorf("got %+v, want Good from the fake model", got)
+	}
+	if provider.calls != 2 {
+		t.Errorf("calls = %d, want 2", provider.calls)
+	}
+
+	provider = &fakeProvider{responses: []string{"Splendid", `{"ranking": "Not_Ranked"}`, "no idea"}}
+	_, err = NewLLMClassifier(provider, "{review}").Classify(context.Background(), req)
+	if !errors.Is(err, ErrUnparseableResponse) {
+		t.Errorf("err = %v, want ErrUnparseableResponse", err)
+	}
+	if provider.calls != maxClassifyAttempts {
+		t.Errorf("calls = %d, want %d", provider.calls, maxClassifyAttempts)
+	}
+}
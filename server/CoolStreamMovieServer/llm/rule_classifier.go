@@ -0,0 +1,105 @@
+package llm
+
+import (
+	"context"
+	"math"
+	"slices"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/textutil"
+)
+
+const ruleClassifierModel = "rules"
+
+var (
+	positiveWords = wordSet(
+		"amazing", "beautiful", "best", "brilliant", "captivating", "charming",
+		"delightful", "enjoyable", "enjoyed", "excellent", "fantastic", "fun",
+		"good", "great", "gripping", "hilarious", "incredible", "love", "loved",
+		"masterpiece", "memorable", "moving", "outstanding", "perfect",
+		"powerful", "recommend", "stunning", "superb", "touching", "wonderful",
+	)
+	negativeWords = wordSet(
+		"annoying", "awful", "bad", "bland", "boring", "confusing", "disappointing",
+		"dull", "forgettable", "hate", "hated", "horrible", "lame", "mediocre",
+		"mess", "poor", "pointless", "predictable", "ridiculous", "slow",
+		"stupid", "terrible", "tedious", "waste", "weak", "worst",
+	)
+	negations = wordSet("not", "no", "never", "hardly", "barely", "t", "nothing", "without")
+)
+
+// RuleClassifier scores a review by counting positive and negative keywords,
+// flipping those preceded by a negation, and maps the score onto the scale.
+// It needs no network access and always gives the same answer for the same
+// input, which makes it the offline fallback and the classifier for tests.
+//
+// Rankings are assumed to be ordered best first by ascending RankingValue,
+// the order recommendations are sorted in.
+type RuleClassifier struct{}
+
+func NewRuleClassifier() *RuleClassifier {
+	return &RuleClassifier{}
+}
+
+func (c *RuleClassifier) Model() string {
+	return ruleClassifierModel
+}
+
+func (c *RuleClassifier) Classify(_ context.Context, req ClassifyRequest) (Classification, error) {
+	candidates := classifiable(req.Rankings)
+	if len(candidates) == 0 {
+		return Classification{}, ErrNoRankings
+	}
+	slices.SortFunc(candidates, func(a, b models.Ranking) int { return a.RankingValue - b.RankingValue })
+
+	score := sentimentScore(req.Review)
+	// score 1 maps to the best ranking, -1 to the worst and 0 to the middle.
+	index := int(math.Round((1 - score) / 2 * float64(len(candidates)-1)))
+	return Classification{Ranking: candidates[index], Model: ruleClassifierModel}, nil
+}
+
+// sentimentScore returns (positive - negative) / (positive + negative), or 0
+// when the review has no sentiment keywords.
+func sentimentScore(review string) float64 {
+	words := textutil.Words(review)
+	positive, negative := 0, 0
+	for i, word := range words {
+		polarity := 0
+		switch {
+		case positiveWords[word]:
+			polarity = 1
+		case negativeWords[word]:
+			polarity = -1
+		default:
+			continue
+		}
+		// A negation up to two words back flips the keyword, unless another
+		// keyword sits in between and already took it.
+		for j := i - 1; j >= max(0, i-2); j-- {
+			if positiveWords[words[j]] || negativeWords[words[j]] {
+				break
+			}
+			if negations[words[j]] {
+				polarity = -polarity
+				break
+			}
+		}
+		if polarity > 0 {
+			positive++
+		} else {
+			negative++
+		}
+	}
+	if positive+negative == 0 {
+		return 0
+	}
+	return float64(positive-negative) / float64(positive+negative)
+}
+
+func wordSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		set[word] = true
+	}
+	return set
+}
@@ -0,0 +1,109 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+)
+
+// testRankings is a five step scale, best first, with the unranked sentinel
+// listed first to make sure it is never picked.
+var testRankings = []models.Ranking{
+	{RankingValue: 999, RankingName: "Not_Ranked", Unranked: true},
+	{RankingValue: 1, RankingName: "Excellent"},
+	{RankingValue: 2, RankingName: "Good"},
+	{RankingValue: 3, RankingName: "Okay"},
+	{RankingValue: 4, RankingName: "Bad"},
+	{RankingValue: 5, RankingName: "Terrible"},
+}
+
+// fakeProvider answers with responses in turn, repeating the last one, or
+// fails with err.
+type fakeProvider struct {
+	responses []string
+	err       error
+	calls     int
+}
+
+func (p *fakeProvider) Generate(_ context.Context, _ string, _ ...GenerateOption) (string, error) {
+	p.calls++
+	if p.err != nil {
+		return "", p.err
+	}
+	return p.responses[min(p.calls, len(p.responses))-1], nil
+}
+
+func (p *fakeProvider) Model() string {
+	return "fake"
+}
+
+func TestRuleClassifier(t *testing.T) {
+	tests := []struct {
+		name   string
+		review string
+		want   string
+	}{
+		{"positive", "A brilliant, moving film. I loved every minute of it.", "Excellent"},
+		{"negative", "Boring and predictable, a waste of two hours.", "Terrible"},
+		{"neutral", "The film follows a family of four across the city.", "Okay"},
+		{"no keywords", "", "Okay"},
+		{"negated positive", "It was not good and never fun.", "Terrible"},
+		{"negated negative", "Not boring at all, never dull.", "Excellent"},
+		{"mixed", "Great acting, but a slow and boring plot.", "Bad"},
+	}
+	classifier := NewRuleClassifier()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := classifier.Classify(context.Background(), ClassifyRequest{Review: tt.review, Rankings: testRankings})
+			if err != nil {
+				t.Fatalf("Classify: %v", err)
+			}
+			if got.Ranking.RankingName != tt.want {
+				t.Errorf("ranking = %q, want %q", got.Ranking.RankingName, tt.want)
+			}
+			if got.Model != ruleClassifierModel {
+				t.Errorf("model = %q, want %q", got.Model, ruleClassifierModel)
+			}
+		})
+	}
+}
+
+func TestRuleClassifierNoRankings(t *testing.T) {
+	for name, rankings := range map[string][]models.Ranking{
+		"empty":         nil,
+		"only unranked": testRankings[:1],
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewRuleClassifier().Classify(context.Background(), ClassifyRequest{Review: "great", Rankings: rankings})
+			if !errors.Is(err, ErrNoRankings) {
+				t.Errorf("err = %v, want ErrNoRankings", err)
+			}
+		})
+	}
+}
+
+func TestFallbackClassifier(t *testing.T) {
+	req := ClassifyRequest{Review: "A wonderful film.", Rankings: testRankings}
+
+	unreachable := &FallbackClassifier{
+		Primary:  NewLLMClassifier(&fakeProvider{err: errors.New("connection refused")}, "{review}"),
+		Fallback: NewRuleClassifier(),
+	}
+	got, err := unreachable.Classify(context.Background(), req)
+	if err != nil {
+		t.Fatalf("provider error: Classify: %v", err)
+	}
+	if got.Model != ruleClassifierModel || got.Ranking.RankingName != "Excellent" {
+		t.Errorf("provider error: got %+v, want the rule classifier's Excellent", got)
+	}
+
+	unparseable := &FallbackClassifier{
+		Primary:  NewLLMClassifier(&fakeProvider{responses: []string{"I cannot say."}}, "{review}"),
+		Fallback: NewRuleClassifier(),
+	}
+	if _, err := unparseable.Classify(context.Background(), req); !errors.Is(err, ErrUnparseableResponse) {
+		t.Errorf("unparseable answer: err = %v, want ErrUnparseableResponse", err)
+	}
+}
@@ -0,0 +1,47 @@
+package mailer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// NewMailerFromEnv builds the mailer selected by MAILER: "smtp", "file" or
+// "log". Without MAILER, mail is dropped with a warning; the log mailer
+// writes live reset links to the log and must be chosen explicitly, for
+// local development only. SMTP reads SMTP_HOST, SMTP_PORT (default 587),
+// SMTP_USERNAME, SMTP_PASSWORD and MAIL_FROM, and needs APP_URL for the
+// links it sends; file writes to MAILER_DIR (default "mail").
+func NewMailerFromEnv() (Mailer, error) {
+	switch kind := os.Getenv("MAILER"); kind {
+	case "":
+		log.Warn().Msg("MAILER is not set, password reset and verification emails will not be sent")
+		return NoopMailer{}, nil
+	case "log":
+		log.Warn().Msg("MAILER=log writes emails, including password reset links, to the log; use it for local development only")
+		return LogMailer{}, nil
+	case "file":
+		dir := os.Getenv("MAILER_DIR")
+		if dir == "" {
+			dir = "mail"
+		}
+		return &FileMailer{Dir: dir}, nil
+	case "smtp":
+		m := &SMTPMailer{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     os.Getenv("SMTP_PORT"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("MAIL_FROM"),
+		}
+		if m.Host == "" || m.From == "" || os.Getenv("APP_URL") == "" {
+			return nil, errors.New("MAILER=smtp requires SMTP_HOST, MAIL_FROM and APP_URL")
+		}
+		if m.Port == "" {
+			m.Port = "587"
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("unknown MAILER %q", kind)
+	}
+}
@@ -0,0 +1,44 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// NoopMailer drops every message, logging only its recipient and subject. It
+// is the mailer when none is configured.
+type NoopMailer struct{}
+
+func (NoopMailer) Send(ctx context.Context, msg Message) error {
+	log.Warn().Str("to", msg.To).Str("subject", msg.Subject).Msg("mail not sent, no mailer configured")
+	return nil
+}
+
+// LogMailer writes messages to the log instead of sending them, for local
+// development only: the bodies hold live action tokens.
+type LogMailer struct{}
+
+func (LogMailer) Send(ctx context.Context, msg Message) error {
+	log.Warn().Str("to", msg.To).Str("subject", msg.Subject).Str("body", msg.Body).Msg("mail not sent, logged instead")
+	return nil
+}
+
+// FileMailer writes each message to its own file in Dir, so that tests and
+// local setups can read what would have been sent.
+type FileMailer struct {
+	Dir string
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405"), bson.NewObjectID().Hex())
+	content := fmt.Sprintf("To: %s\nSubject: %s\n\n%s", headerValue(msg.To), headerValue(msg.Subject), msg.Body)
+	return os.WriteFile(filepath.Join(m.Dir, name), []byte(content), 0o600)
+}
@@ -0,0 +1,29 @@
+// Package mailer sends the emails of the account flows.
+package mailer
+
+import (
+	"context"
+	"strings"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/logger"
+)
+
+var log = logger.GetLogger()
+
+// Message is a plain text email to a single recipient.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// headerValue keeps a value on one header line so that it cannot inject
+// further headers.
+func headerValue(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
@@ -1,9 +1,20 @@
 package main
 
 import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/routes"
 
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/controllers"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/database"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/llm"
 	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/logger"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/mailer"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/moderation"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"github.com/rs/zerolog"
@@ -20,15 +31,100 @@ func init() {
 }
 
 func main() {
+	if code := runCommand(os.Args[1:]); code >= 0 {
+		os.Exit(code)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	if err := database.EnsureCollections(ctx); err != nil {
+		log.Error().Err(err).Msg("failed to create database collections")
+	}
+	if err := database.EnsureIndexes(ctx); err != nil {
+		log.Error().Err(err).Msg("failed to create database indexes")
+	}
+	if err := controllers.BackfillMovieVersions(ctx); err != nil {
+		log.Error().Err(err).Msg("failed to backfill movie versions")
+	}
+	if err := controllers.MigrateUnrankedSentinel(ctx); err != nil {
+		log.Error().Err(err).Msg("failed to migrate the unranked ranking")
+	}
+	if err := controllers.SeedGenresFromMovies(ctx); err != nil {
+		log.Error().Err(err).Msg("failed to seed genres")
+	}
+	if err := controllers.BackfillSearchNgrams(ctx); err != nil {
+		log.Error().Err(err).Msg("failed to backfill movie search ngrams")
+	}
+	if err := controllers.SeedPromptTemplate(ctx); err != nil {
+		log.Error().Err(err).Msg("failed to seed the prompt template")
+	}
+	if err := controllers.MigrateEmailVerified(ctx); err != nil {
+		log.Error().Err(err).Msg("failed to migrate email verification")
+	}
+	cancel()
+
+	provider, err := llm.NewProviderFromEnv()
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to configure the LLM provider")
+	}
+	mail, err := mailer.NewMailerFromEnv()
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to configure the mailer")
+	}
+	classifier := llm.NewCachingClassifier(llm.NewReviewClassifier(provider), controllers.NewClassificationStore(), classificationCacheSize())
+	controllers.StartReviewWorkers(context.Background(), classifier, reviewWorkerCount())
+	go controllers.WatchRerankRuns(context.Background(), classifier)
+
 	router := gin.Default()
+	// The client IP feeds the per-IP login and reset limits. Gin trusts
+	// X-Forwarded-For from anyone by default, so only the proxies listed in
+	// TRUSTED_PROXIES, comma separated, are trusted, and none when it is unset.
+	var trustedProxies []string
+	if proxies := os.Getenv("TRUSTED_PROXIES"); proxies != "" {
+		trustedProxies = strings.Fields(strings.ReplaceAll(proxies, ",", " "))
+	} else {
+		log.Warn().Msg("TRUSTED_PROXIES is not set, X-Forwarded-For is ignored; behind a reverse proxy every client shares the proxy's IP in the login limits")
+	}
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatal().Err(err).Msg("invalid TRUSTED_PROXIES")
+	}
 	router.GET("/hello", func(ctx *gin.Context) {
 		ctx.String(200, "Hello, CoolStreamMovieServer!")
 	})
 
-	routes.SetupUnprotectedRoutes(router)
-	routes.SetupProtectedRoutes(router)
+	deps := routes.Dependencies{
+		Classifier: classifier,
+		Moderator:  moderation.NewPipelineFromEnv(provider),
+		Mailer:     mail,
+		// User review sentiment shares the review workers, and so their
+		// bound, with admin reviews.
+		ClassifyUserReviews: os.Getenv("CLASSIFY_USER_REVIEWS") == "true",
+	}
+	routes.SetupUnprotectedRoutes(router, deps)
+	routes.SetupProtectedRoutes(router, deps)
 
 	if err := router.Run(":8080"); err != nil {
 		log.Fatal().Err(err).Msg("Failed to start the server")
 	}
 }
+
+// classificationCacheSize reads CLASSIFICATION_CACHE_SIZE, the number of
+// classifications kept in memory.
+func classificationCacheSize() int {
+	const defaultSize = 10000
+	size, err := strconv.Atoi(os.Getenv("CLASSIFICATION_CACHE_SIZE"))
+	if err != nil || size < 1 {
+		return defaultSize
+	}
+	return size
+}
+
+// reviewWorkerCount reads REVIEW_WORKERS, the number of goroutines
+// classifying queued reviews.
+func reviewWorkerCount() int {
+	const defaultWorkers = 4
+	workers, err := strconv.Atoi(os.Getenv("REVIEW_WORKERS"))
+	if err != nil || workers < 1 {
+		return defaultWorkers
+	}
+	return workers
+}
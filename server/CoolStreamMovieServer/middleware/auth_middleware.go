@@ -12,19 +12,28 @@ func AuthMiddleWare() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		token, err := utils.GetAccessToken(c)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-			c.Abort()
+			abortWithError(c, http.StatusUnauthorized, "unauthorized", err.Error())
 			return
 		}
 		claims, err := utils.ValidateToken(token)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
+			abortWithError(c, http.StatusUnauthorized, "unauthorized", "Invalid token")
+			return
+		}
+		revoked, err := utils.IsTokenRevoked(claims)
+		if err != nil {
+			abortWithError(c, http.StatusInternalServerError, "internal_error", "Failed to verify token")
+			return
+		}
+		if revoked {
+			abortWithError(c, http.StatusUnauthorized, "unauthorized", "Token has been revoked")
 			return
 		}
 
+		c.Set("claims", claims)
 		c.Set("userId", claims.UID)
 		c.Set("role", claims.Role)
+		c.Set("emailVerified", claims.IsEmailVerified())
 		c.Next()
 	}
 }
@@ -0,0 +1,9 @@
+package middleware
+
+import "github.com/gin-gonic/gin"
+
+// abortWithError writes the same {"error", "code"} envelope as the
+// controllers and stops the handler chain.
+func abortWithError(c *gin.Context, status int, code, message string) {
+	c.AbortWithStatusJSON(status, gin.H{"error": message, "code": code})
+}
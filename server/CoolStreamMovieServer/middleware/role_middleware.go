@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+)
+
+type Permission string
+
+const (
+	PermMovieRead      Permission = "movie:read"
+	PermMovieWrite     Permission = "movie:write"
+	PermAdminReview    Permission = "review:admin"
+	PermRankingWrite   Permission = "ranking:write"
+	PermSessionManage  Permission = "session:manage"
+	PermRecommendation Permission = "recommendation:read"
+	PermUserAdmin      Permission = "user:admin"
+	PermCatalogExport  Permission = "catalog:export"
+	PermGenreWrite     Permission = "genre:write"
+	PermPromptManage   Permission = "prompt:manage"
+	PermReviewWrite    Permission = "review:write"
+	PermModerate       Permission = "review:moderate"
+	PermWatchlist      Permission = "watchlist:manage"
+	PermWatchHistory   Permission = "history:manage"
+	PermProfile        Permission = "profile:manage"
+)
+
+var rolePermissions = map[string][]Permission{
+	models.RoleAdmin: {
+		PermMovieRead,
+		PermMovieWrite,
+		PermAdminReview,
+		PermRankingWrite,
+		PermSessionManage,
+		PermRecommendation,
+		PermUserAdmin,
+		PermCatalogExport,
+		PermGenreWrite,
+		PermPromptManage,
+		PermReviewWrite,
+		PermModerate,
+		PermWatchlist,
+		PermWatchHistory,
+		PermProfile,
+	},
+	models.RoleUser: {
+		PermMovieRead,
+		PermSessionManage,
+		PermRecommendation,
+		PermReviewWrite,
+		PermWatchlist,
+		PermWatchHistory,
+		PermProfile,
+	},
+}
+
+// unverifiedPermissions are all that is left of a role until the user has
+// verified their email address: browsing, managing their session and fixing
+// up their profile.
+var unverifiedPermissions = []Permission{
+	PermMovieRead,
+	PermSessionManage,
+	PermProfile,
+}
+
+func HasPermission(role string, permission Permission) bool {
+	for _, granted := range rolePermissions[role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireRole only lets through callers whose role is one of roles. It must
+// run after AuthMiddleWare; a request without a role is unauthenticated.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, ok := roleFromContext(c)
+		if !ok {
+			abortWithError(c, http.StatusUnauthorized, "unauthorized", "Authentication required")
+			return
+		}
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+		abortWithError(c, http.StatusForbidden, "forbidden", "Insufficient role")
+	}
+}
+
+// RequirePermission only lets through callers whose role grants every one of
+// permissions, and who have verified their email address unless all of
+// permissions are left to unverified users. It must run after AuthMiddleWare.
+func RequirePermission(permissions ...Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, ok := roleFromContext(c)
+		if !ok {
+			abortWithError(c, http.StatusUnauthorized, "unauthorized", "Authentication required")
+			return
+		}
+		verified := c.GetBool("emailVerified")
+		for _, permission := range permissions {
+			if !HasPermission(role, permission) {
+				abortWithError(c, http.StatusForbidden, "forbidden", "Insufficient permissions")
+				return
+			}
+			if !verified && !slices.Contains(unverifiedPermissions, permission) {
+				abortWithError(c, http.StatusForbidden, "email_not_verified", "Verify your email address first")
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+func roleFromContext(c *gin.Context) (string, bool) {
+	value, exist := c.Get("role")
+	if !exist {
+		return "", false
+	}
+	role, ok := value.(string)
+	return role, ok && role != ""
+}
@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// serve runs guard behind a stand-in for AuthMiddleWare that puts role and
+// emailVerified into the context; an empty role leaves the request
+// unauthenticated.
+func serve(guard gin.HandlerFunc, role string, verified bool) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if role != "" {
+			c.Set("role", role)
+			c.Set("emailVerified", verified)
+		}
+		c.Next()
+	})
+	router.GET("/", guard, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	return w
+}
+
+func errorCode(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var body struct {
+		Code string `json:"code"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode error body %q: %v", w.Body.String(), err)
+	}
+	return body.Code
+}
+
+func TestRequirePermission(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       string
+		verified   bool
+		permission Permission
+		wantStatus int
+		wantCode   string
+	}{
+		{"no role", "", false, PermMovieRead, http.StatusUnauthorized, "unauthorized"},
+		{"user writing movies", models.RoleUser, true, PermMovieWrite, http.StatusForbidden, "forbidden"},
+		{"user reviewing as admin", models.RoleUser, true, PermAdminReview, http.StatusForbidden, "forbidden"},
+		{"admin writing movies", models.RoleAdmin, true, PermMovieWrite, http.StatusOK, ""},
+		{"admin reviewing", models.RoleAdmin, true, PermAdminReview, http.StatusOK, ""},
+		{"user reading movies", models.RoleUser, true, PermMovieRead, http.StatusOK, ""},
+		{"unverified user writing reviews", models.RoleUser, false, PermReviewWrite, http.StatusForbidden, "email_not_verified"},
+		{"unverified admin writing movies", models.RoleAdmin, false, PermMovieWrite, http.StatusForbidden, "email_not_verified"},
+		{"unverified user editing profile", models.RoleUser, false, PermProfile, http.StatusOK, ""},
+		{"unverified user without the role's permission", models.RoleUser, false, PermMovieWrite, http.StatusForbidden, "forbidden"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(RequirePermission(tt.permission), tt.role, tt.verified)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantCode != "" {
+				if code := errorCode(t, w); code != tt.wantCode {
+					t.Errorf("code = %q, want %q", code, tt.wantCode)
+				}
+			}
+		})
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       string
+		wantStatus int
+	}{
+		{"no role", "", http.StatusUnauthorized},
+		{"user", models.RoleUser, http.StatusForbidden},
+		{"admin", models.RoleAdmin, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(RequireRole(models.RoleAdmin), tt.role, true)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
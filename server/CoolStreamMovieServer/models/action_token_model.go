@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// Action token purposes.
+const (
+	PurposePasswordReset = "password_reset"
+	PurposeVerifyEmail   = "verify_email"
+)
+
+// ActionToken records an emailed single-use token by its jti. UsedAt is set
+// once the token has been redeemed.
+type ActionToken struct {
+	JTI       string     `bson:"jti"`
+	UserID    string     `bson:"user_id"`
+	Purpose   string     `bson:"purpose"`
+	CreatedAt time.Time  `bson:"created_at"`
+	ExpiresAt time.Time  `bson:"expires_at"`
+	UsedAt    *time.Time `bson:"used_at,omitempty"`
+}
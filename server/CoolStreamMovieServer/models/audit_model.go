@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	AuditRoleChanged  = "role_changed"
+	AuditUserDisabled = "user_disabled"
+	AuditUserEnabled  = "user_enabled"
+	AuditUserDeleted  = "user_deleted"
+	AuditUserUnlocked = "user_unlocked"
+
+	AuditReviewApproved = "review_approved"
+	AuditReviewRejected = "review_rejected"
+	AuditUserBanned     = "user_banned"
+)
+
+type AuditLog struct {
+	ID           bson.ObjectID `bson:"_id,omitempty"       json:"_id,omitempty"`
+	ActorID      string        `bson:"actor_id"            json:"actor_id"`
+	TargetUserID string        `bson:"target_user_id"      json:"target_user_id"`
+	Action       string        `bson:"action"              json:"action"`
+	OldRole      string        `bson:"old_role,omitempty"  json:"old_role,omitempty"`
+	NewRole      string        `bson:"new_role,omitempty"  json:"new_role,omitempty"`
+	ReviewID     string        `bson:"review_id,omitempty" json:"review_id,omitempty"`
+	Reason       string        `bson:"reason,omitempty"    json:"reason,omitempty"`
+	CreatedAt    time.Time     `bson:"created_at"          json:"created_at"`
+}
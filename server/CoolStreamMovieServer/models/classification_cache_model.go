@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// CachedClassification is a stored classifier answer, keyed by the hash of
+// the prompt, model, ranking scale and review it was produced from.
+type CachedClassification struct {
+	Key           string    `bson:"_id"`
+	Ranking       Ranking   `bson:"ranking"`
+	Model         string    `bson:"model"`
+	PromptVersion int       `bson:"prompt_version"`
+	CreatedAt     time.Time `bson:"created_at"`
+}
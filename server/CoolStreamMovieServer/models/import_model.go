@@ -0,0 +1,19 @@
+package models
+
+// ImportReport summarizes a bulk movie import. In a dry run Inserted and
+// Updated count what would have been written.
+type ImportReport struct {
+	DryRun          bool             `json:"dry_run"`
+	Processed       int              `json:"processed"`
+	Inserted        int              `json:"inserted"`
+	Updated         int              `json:"updated"`
+	Failed          int              `json:"failed"`
+	Errors          []ImportRowError `json:"errors"`
+	ErrorsTruncated bool             `json:"errors_truncated,omitempty"`
+}
+
+type ImportRowError struct {
+	Row    int    `json:"row"`
+	ImdbID string `json:"imdb_id,omitempty"`
+	Error  string `json:"error"`
+}
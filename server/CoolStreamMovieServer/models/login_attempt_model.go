@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+)
+
+// LoginAttempt counts the recent failed logins for one key, an email
+// address or a client IP. The document expires a while after the last
+// failure, which resets the count.
+type LoginAttempt struct {
+	Key           string     `bson:"key"`
+	Failures      int        `bson:"failures"`
+	LastFailureAt time.Time  `bson:"last_failure_at"`
+	LockedUntil   *time.Time `bson:"locked_until,omitempty"`
+	ExpiresAt     time.Time  `bson:"expires_at"`
+}
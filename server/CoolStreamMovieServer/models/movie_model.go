@@ -9,9 +9,13 @@ type Genre struct {
 	GenreName string `bson:"genre_name" json:"genre_name" validate:"required"`
 }
 
+// Ranking is one step of the review sentiment scale. At most one ranking is
+// the Unranked sentinel given to movies that have not been classified; it is
+// never offered to the classifier.
 type Ranking struct {
-	RankingValue int    `bson:"ranking_value" json:"ranking_value" validate:"required"`
-	RankingName  string `bson:"ranking_name"  json:"ranking_name"  validate:"required"`
+	RankingValue int    `bson:"ranking_value"      json:"ranking_value"      validate:"required"`
+	RankingName  string `bson:"ranking_name"       json:"ranking_name"       validate:"required"`
+	Unranked     bool   `bson:"unranked,omitempty" json:"unranked,omitempty"`
 }
 
 type Movie struct {
@@ -23,4 +27,17 @@ type Movie struct {
 	Genre       []Genre       `bson:"genre"        json:"genre"        validate:"required,dive"`
 	AdminReview string        `bson:"admin_review" json:"admin_review"`
 	Ranking     Ranking       `bson:"ranking"      json:"ranking"      validate:"required"`
+	Version     int64         `bson:"version"      json:"version"`
+	TitleNgrams []string      `bson:"title_ngrams,omitempty" json:"-"`
+	// RankingStatus and ReviewJobID track the background classification of
+	// AdminReview; only the latest job may write the ranking.
+	RankingStatus string `bson:"ranking_status,omitempty" json:"ranking_status,omitempty"`
+	ReviewJobID   string `bson:"review_job_id,omitempty"  json:"review_job_id,omitempty"`
+	// Classification records the prompt version and model behind Ranking.
+	Classification *ReviewClassification `bson:"classification,omitempty" json:"classification,omitempty"`
+	// User ratings, maintained by the review endpoints only.
+	RatingSum     int64   `bson:"rating_sum"     json:"-"`
+	RatingCount   int64   `bson:"rating_count"   json:"rating_count"`
+	AverageRating float64 `bson:"average_rating" json:"average_rating"`
+	ReviewCount   int64   `bson:"review_count"   json:"review_count"`
 }
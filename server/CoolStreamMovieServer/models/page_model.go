@@ -0,0 +1,17 @@
+package models
+
+// PageMeta describes one page of a listing. Page and TotalPages are only set
+// for page-number pagination; NextCursor is set whenever more results follow
+// and can be used to continue with cursor pagination.
+type PageMeta struct {
+	Page       int64  `json:"page,omitempty"`
+	Limit      int64  `json:"limit"`
+	TotalCount int64  `json:"total_count"`
+	TotalPages int64  `json:"total_pages,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type PageLinks struct {
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// PromptTemplate is one version of the review classification prompt.
+// Versions are never edited; changing the prompt adds a version and at most
+// one version is active.
+type PromptTemplate struct {
+	ID        bson.ObjectID `bson:"_id,omitempty"        json:"-"`
+	Version   int           `bson:"version"              json:"version"`
+	Template  string        `bson:"template"             json:"template"`
+	Note      string        `bson:"note,omitempty"       json:"note,omitempty"`
+	Active    bool          `bson:"active"               json:"active"`
+	CreatedBy string        `bson:"created_by,omitempty" json:"created_by,omitempty"`
+	CreatedAt time.Time     `bson:"created_at"           json:"created_at"`
+}
+
+// ReviewClassification records what produced a movie's ranking.
+// PromptVersion is 0 when the prompt came from BASE_PROMPT_TEMPLATE or no
+// prompt was involved.
+type ReviewClassification struct {
+	PromptVersion int       `bson:"prompt_version" json:"prompt_version"`
+	Model         string    `bson:"model"          json:"model"`
+	ClassifiedAt  time.Time `bson:"classified_at"  json:"classified_at"`
+}
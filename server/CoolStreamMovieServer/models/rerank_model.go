@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	RerankRunning   = "running"
+	RerankCompleted = "completed"
+	RerankCancelled = "cancelled"
+	RerankFailed    = "failed"
+)
+
+// RerankRun is one pass re-classifying every admin review in the catalog.
+// Movies are walked in _id order and Checkpoint is the last movie of the last
+// finished batch, so a run interrupted by a crash resumes where it stopped.
+// The owning server proves it is alive through HeartbeatAt.
+type RerankRun struct {
+	ID          bson.ObjectID `bson:"_id"                   json:"run_id"`
+	Status      string        `bson:"status"                json:"status"`
+	DryRun      bool          `bson:"dry_run"               json:"dry_run"`
+	Trigger     string        `bson:"trigger"               json:"trigger"`
+	Owner       string        `bson:"owner"                 json:"-"`
+	Checkpoint  bson.ObjectID `bson:"checkpoint"            json:"-"`
+	Total       int64         `bson:"total"                 json:"total"`
+	Processed   int64         `bson:"processed"             json:"processed"`
+	Changed     int64         `bson:"changed"               json:"changed"`
+	Unchanged   int64         `bson:"unchanged"             json:"unchanged"`
+	Failed      int64         `bson:"failed"                json:"failed"`
+	LastError   string        `bson:"last_error,omitempty"  json:"last_error,omitempty"`
+	StartedAt   time.Time     `bson:"started_at"            json:"started_at"`
+	HeartbeatAt time.Time     `bson:"heartbeat_at"          json:"heartbeat_at"`
+	FinishedAt  *time.Time    `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+}
+
+// RerankDiff records a ranking a dry run would change.
+type RerankDiff struct {
+	ID         bson.ObjectID `bson:"_id,omitempty" json:"-"`
+	RunID      bson.ObjectID `bson:"run_id"        json:"run_id"`
+	ImdbID     string        `bson:"imdb_id"       json:"imdb_id"`
+	Title      string        `bson:"title"         json:"title"`
+	OldRanking Ranking       `bson:"old_ranking"   json:"old_ranking"`
+	NewRanking Ranking       `bson:"new_ranking"   json:"new_ranking"`
+	CreatedAt  time.Time     `bson:"created_at"    json:"created_at"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import (
+	"time"
+)
+
+// PasswordResetRequest records one password reset request against a key, an
+// email address or a client IP, until it leaves the throttling window at
+// ExpiresAt.
+type PasswordResetRequest struct {
+	Key       string    `bson:"key"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	JobQueued     = "queued"
+	JobRunning    = "running"
+	JobSucceeded  = "succeeded"
+	JobSuperseded = "superseded"
+	JobDead       = "dead"
+)
+
+// Kinds of review job. Jobs stored before kinds existed have none and
+// classify an admin review.
+const (
+	ReviewJobAdmin = "admin_review"
+	ReviewJobUser  = "user_review"
+)
+
+// Ranking status of a movie while its admin review is classified in the
+// background.
+const (
+	RankingPending = "pending"
+	RankingRanked  = "ranked"
+	RankingFailed  = "failed"
+)
+
+// ReviewJob classifies one submitted admin review, or with Kind
+// ReviewJobUser the sentiment of the user review ReviewID, whose text is in
+// ReviewText. Failed attempts are retried at RunAt until MaxAttempts is
+// reached, after which the job is dead and stays in the collection for
+// inspection and manual retry.
+type ReviewJob struct {
+	ID             bson.ObjectID         `bson:"_id"                      json:"job_id"`
+	Kind           string                `bson:"kind,omitempty"           json:"kind,omitempty"`
+	ImdbID         string                `bson:"imdb_id"                  json:"imdb_id"`
+	AdminReview    string                `bson:"admin_review"             json:"admin_review"`
+	ReviewID       *bson.ObjectID        `bson:"review_id,omitempty"      json:"review_id,omitempty"`
+	ReviewText     string                `bson:"review_text,omitempty"    json:"review_text,omitempty"`
+	Status         string                `bson:"status"                   json:"status"`
+	Attempts       int                   `bson:"attempts"                 json:"attempts"`
+	MaxAttempts    int                   `bson:"max_attempts"             json:"max_attempts"`
+	LastError      string                `bson:"last_error,omitempty"     json:"last_error,omitempty"`
+	Result         *Ranking              `bson:"result,omitempty"         json:"result,omitempty"`
+	Classification *ReviewClassification `bson:"classification,omitempty" json:"classification,omitempty"`
+	RunAt          time.Time             `bson:"run_at"                   json:"run_at"`
+	LockedUntil    time.Time             `bson:"locked_until,omitempty"   json:"-"`
+	CreatedAt      time.Time             `bson:"created_at"               json:"created_at"`
+	UpdatedAt      time.Time             `bson:"updated_at"               json:"updated_at"`
+	CompletedAt    *time.Time            `bson:"completed_at,omitempty"   json:"completed_at,omitempty"`
+}
+
+// Finished reports whether the job will not change any more by itself.
+func (j ReviewJob) Finished() bool {
+	return j.Status == JobSucceeded || j.Status == JobSuperseded || j.Status == JobDead
+}
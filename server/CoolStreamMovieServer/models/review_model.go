@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Review statuses. Only published reviews are listed and count towards the
+// movie's rating.
+const (
+	ReviewPublished = "published"
+	ReviewFlagged   = "flagged"
+	ReviewRejected  = "rejected"
+)
+
+// Review is a user's star rating of a movie with an optional text. Each user
+// reviews a movie at most once. ModerationHistory keeps the moderation of
+// earlier versions, oldest first, so an edit never erases a decision.
+type Review struct {
+	ID                bson.ObjectID         `bson:"_id,omitempty"                json:"review_id"`
+	ImdbID            string                `bson:"imdb_id"                      json:"imdb_id"`
+	UserID            string                `bson:"user_id"                      json:"user_id"`
+	Author            string                `bson:"author"                       json:"author"`
+	Rating            int                   `bson:"rating"                       json:"rating"                       validate:"required,min=1,max=5"`
+	Text              string                `bson:"text"                         json:"text"                         validate:"max=5000"`
+	Sentiment         *Ranking              `bson:"sentiment,omitempty"          json:"sentiment,omitempty"`
+	Classification    *ReviewClassification `bson:"classification,omitempty"     json:"classification,omitempty"`
+	Status            string                `bson:"status"                       json:"status"`
+	Moderation        *ReviewModeration     `bson:"moderation,omitempty"         json:"moderation,omitempty"`
+	ModerationHistory []ReviewModeration    `bson:"moderation_history,omitempty" json:"moderation_history,omitempty"`
+	CreatedAt         time.Time             `bson:"created_at"                   json:"created_at"`
+	UpdatedAt         time.Time             `bson:"updated_at"                   json:"updated_at"`
+}
+
+// PublicReview is the view of a published review shown to every user: it
+// leaves out the author's user id and the moderation record.
+type PublicReview struct {
+	ID        bson.ObjectID `json:"review_id"`
+	ImdbID    string        `json:"imdb_id"`
+	Author    string        `json:"author"`
+	Rating    int           `json:"rating"`
+	Text      string        `json:"text"`
+	Sentiment *Ranking      `json:"sentiment,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// ReviewModeration is the outcome of the automatic checks on a review and the
+// moderator decision on it, if any.
+type ReviewModeration struct {
+	Findings  []ModerationFinding `bson:"findings"             json:"findings"`
+	CheckedAt time.Time           `bson:"checked_at"           json:"checked_at"`
+	Decision  string              `bson:"decision,omitempty"   json:"decision,omitempty"`
+	Reason    string              `bson:"reason,omitempty"     json:"reason,omitempty"`
+	DecidedBy string              `bson:"decided_by,omitempty" json:"decided_by,omitempty"`
+	DecidedAt *time.Time          `bson:"decided_at,omitempty" json:"decided_at,omitempty"`
+}
+
+type ModerationFinding struct {
+	Check  string `bson:"check"  json:"check"`
+	Reason string `bson:"reason" json:"reason"`
+}
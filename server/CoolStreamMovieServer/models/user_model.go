@@ -6,6 +6,11 @@ import (
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
+const (
+	RoleAdmin = "ADMIN"
+	RoleUser  = "USER"
+)
+
 type User struct {
 	ID             bson.ObjectID `bson:"_id,omitempty"    json:"_id,omitempty"`
 	UserID         string        `bson:"user_id"          json:"user_id"`
@@ -18,6 +23,9 @@ type User struct {
 	UpdatedAt      time.Time     `bson:"updated_at"       json:"updated_at"`
 	Token          string        `bson:"token"            json:"token"`
 	RefreshToken   string        `bson:"refresh_token"    json:"refresh_token"`
+	TokenVersion   int           `bson:"token_version"    json:"-"`
+	Disabled       bool          `bson:"disabled"         json:"disabled"`
+	EmailVerified  bool          `bson:"email_verified"   json:"email_verified"`
 	FavoriteGenres []Genre       `bson:"favourite_genres" json:"favourite_genres" validate:"required,dive"`
 }
 
@@ -32,7 +40,23 @@ type UserResponse struct {
 	LastName       string  `json:"last_name"`
 	Email          string  `json:"email"`
 	Role           string  `json:"role"`
+	EmailVerified  bool    `json:"email_verified"`
 	Token          string  `json:"token"`
 	RefreshToken   string  `json:"refresh_token"`
 	FavoriteGenres []Genre `json:"favourite_genres"`
 }
+
+// PublicUser is the view of a user that is safe to return to clients: it
+// never carries the password hash or the stored tokens.
+type PublicUser struct {
+	UserID         string    `json:"user_id"`
+	FirstName      string    `json:"first_name"`
+	LastName       string    `json:"last_name"`
+	Email          string    `json:"email"`
+	Role           string    `json:"role"`
+	Disabled       bool      `json:"disabled"`
+	EmailVerified  bool      `json:"email_verified"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	FavoriteGenres []Genre   `json:"favourite_genres"`
+}
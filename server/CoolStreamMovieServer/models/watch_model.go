@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+)
+
+// WatchEvent is one playback progress report. Events are kept in a time
+// series collection keyed by Meta for WATCH_EVENT_RETENTION_DAYS, 90 by
+// default.
+type WatchEvent struct {
+	Meta      WatchEventMeta `bson:"meta"       json:"meta"`
+	Position  int64          `bson:"position"   json:"position"`
+	Duration  int64          `bson:"duration"   json:"duration"`
+	Completed bool           `bson:"completed"  json:"completed"`
+	WatchedAt time.Time      `bson:"watched_at" json:"watched_at"`
+}
+
+type WatchEventMeta struct {
+	UserID string `bson:"user_id" json:"user_id"`
+	ImdbID string `bson:"imdb_id" json:"imdb_id"`
+}
+
+// WatchProgress is the latest playback state of a movie for a user, with
+// positions and durations in seconds. CompletedAt is set the first time the
+// movie is finished and stays set when it is watched again.
+type WatchProgress struct {
+	UserID      string     `bson:"user_id"                json:"-"`
+	ImdbID      string     `bson:"imdb_id"                json:"imdb_id"`
+	Position    int64      `bson:"position"               json:"position"`
+	Duration    int64      `bson:"duration"               json:"duration"`
+	Completed   bool       `bson:"completed"              json:"completed"`
+	CompletedAt *time.Time `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	UpdatedAt   time.Time  `bson:"updated_at"             json:"updated_at"`
+	Movie       *Movie     `bson:"movie,omitempty"        json:"movie,omitempty"`
+}
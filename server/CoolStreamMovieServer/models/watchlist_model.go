@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// WatchlistEntry is a movie on a user's watchlist. Entries are listed by
+// Position, lowest first; Movie is only filled in when listing.
+type WatchlistEntry struct {
+	ID       bson.ObjectID `bson:"_id,omitempty"   json:"-"`
+	UserID   string        `bson:"user_id"         json:"-"`
+	ImdbID   string        `bson:"imdb_id"         json:"imdb_id"`
+	Position int64         `bson:"position"        json:"position"`
+	AddedAt  time.Time     `bson:"added_at"        json:"added_at"`
+	Movie    *Movie        `bson:"movie,omitempty" json:"movie,omitempty"`
+}
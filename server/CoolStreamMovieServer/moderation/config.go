@@ -0,0 +1,38 @@
+package moderation
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/llm"
+)
+
+const defaultMaxLinks = 1
+
+// NewPipelineFromEnv builds the profanity and link spam checks, plus the LLM
+// check when MODERATION_LLM is "true" and provider is not nil.
+// MODERATION_WORDS adds comma separated words to the profanity list and
+// MODERATION_MAX_LINKS sets the links a review may contain.
+func NewPipelineFromEnv(provider llm.Provider) *Pipeline {
+	var extra []string
+	for word := range strings.SplitSeq(os.Getenv("MODERATION_WORDS"), ",") {
+		if word = strings.TrimSpace(word); word != "" {
+			extra = append(extra, word)
+		}
+	}
+	maxLinks, err := strconv.Atoi(os.Getenv("MODERATION_MAX_LINKS"))
+	if err != nil || maxLinks < 0 {
+		maxLinks = defaultMaxLinks
+	}
+
+	checks := []Check{NewProfanityCheck(extra...), NewLinkSpamCheck(maxLinks)}
+	if os.Getenv("MODERATION_LLM") == "true" {
+		if provider == nil {
+			log.Warn().Msg("MODERATION_LLM is set but no LLM is configured")
+		} else {
+			checks = append(checks, NewLLMCheck(provider))
+		}
+	}
+	return NewPipeline(checks...)
+}
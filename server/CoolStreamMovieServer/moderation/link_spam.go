@@ -0,0 +1,63 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const linkSpamCheckName = "link_spam"
+
+var (
+	linkRe = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+|\b[a-z0-9-]+\.(?:com|net|org|io|ru|cn|xyz|top|info|biz|ly|co)\b(?:/\S*)?`)
+	// shortenerHosts hide where a link goes, which reviews have no need for.
+	shortenerHosts = []string{"bit.ly", "tinyurl.com", "t.co", "goo.gl", "ow.ly", "is.gd", "cutt.ly", "rb.gy"}
+	spamPhrases    = []string{
+		"buy now", "click here", "free money", "promo code", "discount code",
+		"work from home", "make money", "limited offer", "visit my", "check out my",
+		"watch free", "free download", "crypto", "casino",
+	}
+)
+
+// LinkSpamCheck flags reviews that advertise: more than MaxLinks links,
+// links through URL shorteners, or a link next to a typical spam phrase.
+type LinkSpamCheck struct {
+	MaxLinks int
+}
+
+func NewLinkSpamCheck(maxLinks int) *LinkSpamCheck {
+	return &LinkSpamCheck{MaxLinks: maxLinks}
+}
+
+func (l *LinkSpamCheck) Name() string {
+	return linkSpamCheckName
+}
+
+func (l *LinkSpamCheck) Check(_ context.Context, text string) ([]Finding, error) {
+	links := linkRe.FindAllString(text, -1)
+	if len(links) == 0 {
+		return nil, nil
+	}
+	var findings []Finding
+	if len(links) > l.MaxLinks {
+		findings = append(findings, Finding{Check: linkSpamCheckName, Reason: fmt.Sprintf("contains %d links", len(links))})
+	}
+	for _, link := range links {
+		lower := strings.ToLower(link)
+		for _, host := range shortenerHosts {
+			if strings.Contains(lower, host) {
+				findings = append(findings, Finding{Check: linkSpamCheckName, Reason: "links through the URL shortener " + host})
+				break
+			}
+		}
+	}
+	lower := strings.ToLower(text)
+	for _, phrase := range spamPhrases {
+		if strings.Contains(lower, phrase) {
+			findings = append(findings, Finding{Check: linkSpamCheckName, Reason: "link with the spam phrase \"" + phrase + "\""})
+			break
+		}
+	}
+	return findings, nil
+}
@@ -0,0 +1,58 @@
+package moderation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/llm"
+)
+
+const (
+	llmCheckName = "llm"
+	llmPrompt    = "You moderate user reviews of movies. Flag the review if it contains harassment, hate speech, " +
+		"sexual content, threats, personal data, spam or advertising, or is unrelated to the movie. " +
+		"Criticism of the movie, however harsh, is allowed and spoilers are allowed.\n\n" +
+		"Respond only with a JSON object of the form {\"flagged\": true|false, \"reason\": \"<short reason>\"}.\n\n" +
+		"Review:\n"
+)
+
+// LLMCheck asks a language model whether a review breaks the content rules.
+type LLMCheck struct {
+	provider llm.Provider
+}
+
+func NewLLMCheck(provider llm.Provider) *LLMCheck {
+	return &LLMCheck{provider: provider}
+}
+
+func (l *LLMCheck) Name() string {
+	return llmCheckName
+}
+
+func (l *LLMCheck) Check(ctx context.Context, text string) ([]Finding, error) {
+	response, err := l.provider.Generate(ctx, llmPrompt+text, llm.WithJSON())
+	if err != nil {
+		return nil, err
+	}
+	var verdict struct {
+		Flagged *bool  `json:"flagged"`
+		Reason  string `json:"reason"`
+	}
+	start, end := strings.Index(response, "{"), strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("%w: %q", llm.ErrUnparseableResponse, response)
+	}
+	if err := json.Unmarshal([]byte(response[start:end+1]), &verdict); err != nil || verdict.Flagged == nil {
+		return nil, fmt.Errorf("%w: %q", llm.ErrUnparseableResponse, response)
+	}
+	if !*verdict.Flagged {
+		return nil, nil
+	}
+	reason := strings.TrimSpace(verdict.Reason)
+	if reason == "" {
+		reason = "flagged by " + l.provider.Model()
+	}
+	return []Finding{{Check: llmCheckName, Reason: reason}}, nil
+}
@@ -0,0 +1,46 @@
+// Package moderation screens user-written text before it is published
+package moderation
+
+import (
+	"context"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/logger"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+)
+
+var log = logger.GetLogger()
+
+// Finding is a reason a check objects to a text. It is stored with the
+// review as is.
+type Finding = models.ModerationFinding
+
+// Check inspects a text and returns its findings, none when the text is fine.
+type Check interface {
+	Name() string
+	Check(ctx context.Context, text string) ([]Finding, error)
+}
+
+// Pipeline runs every check over a text. A text with findings is flagged and
+// waits for a moderator.
+type Pipeline struct {
+	checks []Check
+}
+
+func NewPipeline(checks ...Check) *Pipeline {
+	return &Pipeline{checks: checks}
+}
+
+// Moderate returns the findings of all checks. A check that fails is logged
+// and skipped, so an unavailable LLM does not hold every review back.
+func (p *Pipeline) Moderate(ctx context.Context, text string) []Finding {
+	var findings []Finding
+	for _, check := range p.checks {
+		checkFindings, err := check.Check(ctx, text)
+		if err != nil {
+			log.Warn().Err(err).Str("check", check.Name()).Msg("moderation check failed")
+			continue
+		}
+		findings = append(findings, checkFindings...)
+	}
+	return findings
+}
@@ -0,0 +1,60 @@
+package moderation
+
+import (
+	"context"
+	"strings"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/textutil"
+)
+
+const profanityCheckName = "profanity"
+
+// defaultProfanity is a deliberately short list; deployments extend it with
+// MODERATION_WORDS.
+var defaultProfanity = []string{
+	"arse", "asshole", "bastard", "bitch", "bollocks", "bullshit", "cock",
+	"crap", "cunt", "dick", "dickhead", "fag", "faggot", "fuck", "fucker",
+	"fucking", "motherfucker", "nigger", "piss", "prick", "pussy", "retard",
+	"shit", "shitty", "slut", "twat", "wanker", "whore",
+}
+
+// leetReplacer undoes the common letter substitutions used to slip words
+// past filters.
+var leetReplacer = strings.NewReplacer(
+	"0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s", "!", "i",
+)
+
+// ProfanityCheck flags texts containing a listed word, including spellings
+// with digits or symbols for letters.
+type ProfanityCheck struct {
+	words map[string]bool
+}
+
+// NewProfanityCheck uses the built-in word list plus extra.
+func NewProfanityCheck(extra ...string) *ProfanityCheck {
+	words := make(map[string]bool, len(defaultProfanity)+len(extra))
+	for _, word := range append(defaultProfanity, extra...) {
+		if word = textutil.Normalize(word); word != "" {
+			words[word] = true
+		}
+	}
+	return &ProfanityCheck{words: words}
+}
+
+func (p *ProfanityCheck) Name() string {
+	return profanityCheckName
+}
+
+func (p *ProfanityCheck) Check(_ context.Context, text string) ([]Finding, error) {
+	seen := map[string]bool{}
+	var findings []Finding
+	for _, candidate := range []string{text, leetReplacer.Replace(strings.ToLower(text))} {
+		for _, word := range textutil.Words(candidate) {
+			if p.words[word] && !seen[word] {
+				seen[word] = true
+				findings = append(findings, Finding{Check: profanityCheckName, Reason: "contains \"" + word + "\""})
+			}
+		}
+	}
+	return findings, nil
+}
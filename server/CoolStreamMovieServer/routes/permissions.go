@@ -0,0 +1,87 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/middleware"
+)
+
+// routePermissions declares the permission required by every protected
+// route, keyed by "METHOD path" exactly as the route is registered.
+var routePermissions = map[string]middleware.Permission{
+	"GET /movie/:imdb_id":               middleware.PermMovieRead,
+	"GET /movie/:imdb_id/reviews":       middleware.PermMovieRead,
+	"POST /movie/:imdb_id/reviews":      middleware.PermReviewWrite,
+	"PUT /movie/:imdb_id/reviews/me":    middleware.PermReviewWrite,
+	"DELETE /movie/:imdb_id/reviews/me": middleware.PermReviewWrite,
+	"POST /addmovie":                    middleware.PermMovieWrite,
+	"PUT /movie/:imdb_id":               middleware.PermMovieWrite,
+	"PATCH /movie/:imdb_id":             middleware.PermMovieWrite,
+	"DELETE /movie/:imdb_id":            middleware.PermMovieWrite,
+	"POST /movies/import":               middleware.PermMovieWrite,
+	"GET /movies/export":                middleware.PermCatalogExport,
+	"POST /genres":                      middleware.PermGenreWrite,
+	"PUT /genres/:genre_id":             middleware.PermGenreWrite,
+	"DELETE /genres/:genre_id":          middleware.PermGenreWrite,
+	"GET /rankings":                     middleware.PermMovieRead,
+	"POST /rankings":                    middleware.PermRankingWrite,
+	"PUT /rankings/:ranking_value":      middleware.PermRankingWrite,
+	"DELETE /rankings/:ranking_value":   middleware.PermRankingWrite,
+	"PATCH /updatereview/:imdb_id":      middleware.PermAdminReview,
+	"GET /reviewjobs":                   middleware.PermAdminReview,
+	"GET /reviewjobs/:job_id":           middleware.PermAdminReview,
+	"GET /reviewjobs/:job_id/events":    middleware.PermAdminReview,
+	"POST /reviewjobs/:job_id/retry":    middleware.PermAdminReview,
+	"GET /me":                           middleware.PermProfile,
+	"PATCH /me":                         middleware.PermProfile,
+	"POST /me/password":                 middleware.PermProfile,
+	"PUT /me/genres":                    middleware.PermProfile,
+	"POST /me/verify-email":             middleware.PermProfile,
+	"GET /me/watchlist":                 middleware.PermWatchlist,
+	"POST /me/watchlist":                middleware.PermWatchlist,
+	"PUT /me/watchlist/order":           middleware.PermWatchlist,
+	"DELETE /me/watchlist/:imdb_id":     middleware.PermWatchlist,
+	"GET /me/history":                   middleware.PermWatchHistory,
+	"POST /me/history":                  middleware.PermWatchHistory,
+	"GET /me/continue-watching":         middleware.PermWatchHistory,
+	"GET /recommendedmovies":            middleware.PermRecommendation,
+	"POST /logout":                      middleware.PermSessionManage,
+	"POST /logout/all":                  middleware.PermSessionManage,
+
+	"GET /admin/users":                   middleware.PermUserAdmin,
+	"PATCH /admin/users/:user_id/role":   middleware.PermUserAdmin,
+	"POST /admin/users/:user_id/disable": middleware.PermUserAdmin,
+	"POST /admin/users/:user_id/enable":  middleware.PermUserAdmin,
+	"POST /admin/users/:user_id/unlock":  middleware.PermUserAdmin,
+	"DELETE /admin/users/:user_id":       middleware.PermUserAdmin,
+	"GET /admin/audit":                   middleware.PermUserAdmin,
+
+	"GET /admin/moderation/reviews":                     middleware.PermModerate,
+	"POST /admin/moderation/reviews/:review_id/approve": middleware.PermModerate,
+	"POST /admin/moderation/reviews/:review_id/reject":  middleware.PermModerate,
+	"POST /admin/moderation/reviews/:review_id/ban":     middleware.PermModerate,
+
+	"GET /admin/prompts":                    middleware.PermPromptManage,
+	"POST /admin/prompts":                   middleware.PermPromptManage,
+	"POST /admin/prompts/preview":           middleware.PermPromptManage,
+	"GET /admin/prompts/:version":           middleware.PermPromptManage,
+	"POST /admin/prompts/:version/activate": middleware.PermPromptManage,
+
+	"POST /admin/rerank":                middleware.PermAdminReview,
+	"GET /admin/rerank":                 middleware.PermAdminReview,
+	"GET /admin/rerank/:run_id":         middleware.PermAdminReview,
+	"GET /admin/rerank/:run_id/diffs":   middleware.PermAdminReview,
+	"POST /admin/rerank/:run_id/cancel": middleware.PermAdminReview,
+	"POST /admin/rerank/:run_id/resume": middleware.PermAdminReview,
+}
+
+// protectedRoute registers handler behind the permission declared for it in
+// routePermissions. A route missing from the map panics at startup rather
+// than being served without authorization.
+func protectedRoute(router gin.IRoutes, method, path string, handler gin.HandlerFunc) {
+	permission, ok := routePermissions[method+" "+path]
+	if !ok {
+		panic("routes: no permission declared for " + method + " " + path)
+	}
+	router.Handle(method, path, middleware.RequirePermission(permission), handler)
+}
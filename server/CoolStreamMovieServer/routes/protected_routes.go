@@ -1,15 +1,93 @@
 package routes
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 
 	controller "github.com/drshashwat/coolstream/server/CoolStreamMovieServer/controllers"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/llm"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/mailer"
 	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/middleware"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/moderation"
 )
 
-func SetupProtectedRoutes(router *gin.Engine) {
+// Dependencies are the services handed to the controllers that need them.
+type Dependencies struct {
+	Classifier llm.ReviewClassifier
+	// ClassifyUserReviews queues the sentiment of user reviews for the
+	// review workers.
+	ClassifyUserReviews bool
+	// Moderator screens user reviews before they are published.
+	Moderator *moderation.Pipeline
+	// Mailer delivers password reset and email verification links.
+	Mailer mailer.Mailer
+}
+
+func SetupProtectedRoutes(router *gin.Engine, deps Dependencies) {
 	router.Use(middleware.AuthMiddleWare())
-	router.GET("/movie/:imdb_id", controller.GetMovie())
-	router.POST("/addmovie", controller.AddMovie())
-	router.GET("/recommendedmovies", controller.GetRecomendedMovies())
+	protectedRoute(router, http.MethodGet, "/movie/:imdb_id", controller.GetMovie())
+	protectedRoute(router, http.MethodGet, "/movie/:imdb_id/reviews", controller.GetMovieReviews())
+	protectedRoute(router, http.MethodPost, "/movie/:imdb_id/reviews", controller.CreateReview(deps.ClassifyUserReviews, deps.Moderator))
+	protectedRoute(router, http.MethodPut, "/movie/:imdb_id/reviews/me", controller.UpdateReview(deps.ClassifyUserReviews, deps.Moderator))
+	protectedRoute(router, http.MethodDelete, "/movie/:imdb_id/reviews/me", controller.DeleteReview())
+	protectedRoute(router, http.MethodPost, "/addmovie", controller.AddMovie())
+	protectedRoute(router, http.MethodPut, "/movie/:imdb_id", controller.ReplaceMovie())
+	protectedRoute(router, http.MethodPatch, "/movie/:imdb_id", controller.PatchMovie())
+	protectedRoute(router, http.MethodDelete, "/movie/:imdb_id", controller.DeleteMovie())
+	protectedRoute(router, http.MethodPost, "/movies/import", controller.ImportMoviesHandler())
+	protectedRoute(router, http.MethodGet, "/movies/export", controller.ExportMovies())
+	protectedRoute(router, http.MethodPost, "/genres", controller.AddGenre())
+	protectedRoute(router, http.MethodPut, "/genres/:genre_id", controller.RenameGenre())
+	protectedRoute(router, http.MethodDelete, "/genres/:genre_id", controller.DeleteGenre())
+	protectedRoute(router, http.MethodGet, "/rankings", controller.ListRankings())
+	protectedRoute(router, http.MethodPost, "/rankings", controller.AddRanking(deps.Classifier))
+	protectedRoute(router, http.MethodPut, "/rankings/:ranking_value", controller.UpdateRanking(deps.Classifier))
+	protectedRoute(router, http.MethodDelete, "/rankings/:ranking_value", controller.DeleteRanking(deps.Classifier))
+	protectedRoute(router, http.MethodPatch, "/updatereview/:imdb_id", controller.AdminReviewUpdate())
+	protectedRoute(router, http.MethodGet, "/reviewjobs", controller.ListReviewJobs())
+	protectedRoute(router, http.MethodGet, "/reviewjobs/:job_id", controller.GetReviewJob())
+	protectedRoute(router, http.MethodGet, "/reviewjobs/:job_id/events", controller.ReviewJobEvents())
+	protectedRoute(router, http.MethodPost, "/reviewjobs/:job_id/retry", controller.RetryReviewJob())
+	protectedRoute(router, http.MethodGet, "/me", controller.GetMe())
+	protectedRoute(router, http.MethodPatch, "/me", controller.UpdateMe())
+	protectedRoute(router, http.MethodPost, "/me/password", controller.ChangePassword())
+	protectedRoute(router, http.MethodPut, "/me/genres", controller.UpdateMyGenres())
+	protectedRoute(router, http.MethodPost, "/me/verify-email", controller.ResendVerificationEmail(deps.Mailer))
+	protectedRoute(router, http.MethodGet, "/me/watchlist", controller.GetWatchlist())
+	protectedRoute(router, http.MethodPost, "/me/watchlist", controller.AddToWatchlist())
+	protectedRoute(router, http.MethodPut, "/me/watchlist/order", controller.ReorderWatchlist())
+	protectedRoute(router, http.MethodDelete, "/me/watchlist/:imdb_id", controller.RemoveFromWatchlist())
+	protectedRoute(router, http.MethodGet, "/me/history", controller.GetWatchHistory())
+	protectedRoute(router, http.MethodPost, "/me/history", controller.ReportWatchProgress())
+	protectedRoute(router, http.MethodGet, "/me/continue-watching", controller.GetContinueWatching())
+	protectedRoute(router, http.MethodGet, "/recommendedmovies", controller.GetRecomendedMovies())
+	protectedRoute(router, http.MethodPost, "/logout", controller.LogoutUser())
+	protectedRoute(router, http.MethodPost, "/logout/all", controller.LogoutAllDevices())
+
+	protectedRoute(router, http.MethodGet, "/admin/users", controller.ListUsers())
+	protectedRoute(router, http.MethodPatch, "/admin/users/:user_id/role", controller.UpdateUserRole())
+	protectedRoute(router, http.MethodPost, "/admin/users/:user_id/disable", controller.DisableUser())
+	protectedRoute(router, http.MethodPost, "/admin/users/:user_id/enable", controller.EnableUser())
+	protectedRoute(router, http.MethodPost, "/admin/users/:user_id/unlock", controller.UnlockUser())
+	protectedRoute(router, http.MethodDelete, "/admin/users/:user_id", controller.DeleteUser())
+	protectedRoute(router, http.MethodGet, "/admin/audit", controller.ListAuditLogs())
+
+	protectedRoute(router, http.MethodGet, "/admin/moderation/reviews", controller.ListModerationQueue())
+	protectedRoute(router, http.MethodPost, "/admin/moderation/reviews/:review_id/approve", controller.ApproveReview())
+	protectedRoute(router, http.MethodPost, "/admin/moderation/reviews/:review_id/reject", controller.RejectReview())
+	protectedRoute(router, http.MethodPost, "/admin/moderation/reviews/:review_id/ban", controller.BanReviewAuthor())
+
+	protectedRoute(router, http.MethodGet, "/admin/prompts", controller.ListPromptTemplates())
+	protectedRoute(router, http.MethodPost, "/admin/prompts", controller.CreatePromptTemplate())
+	protectedRoute(router, http.MethodPost, "/admin/prompts/preview", controller.PreviewPromptTemplate(deps.Classifier))
+	protectedRoute(router, http.MethodGet, "/admin/prompts/:version", controller.GetPromptTemplate())
+	protectedRoute(router, http.MethodPost, "/admin/prompts/:version/activate", controller.ActivatePromptTemplate())
+
+	protectedRoute(router, http.MethodPost, "/admin/rerank", controller.StartRerank(deps.Classifier))
+	protectedRoute(router, http.MethodGet, "/admin/rerank", controller.ListRerankRuns())
+	protectedRoute(router, http.MethodGet, "/admin/rerank/:run_id", controller.GetRerankRun())
+	protectedRoute(router, http.MethodGet, "/admin/rerank/:run_id/diffs", controller.ListRerankDiffs())
+	protectedRoute(router, http.MethodPost, "/admin/rerank/:run_id/cancel", controller.CancelRerank())
+	protectedRoute(router, http.MethodPost, "/admin/rerank/:run_id/resume", controller.ResumeRerank(deps.Classifier))
 }
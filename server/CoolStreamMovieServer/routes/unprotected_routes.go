@@ -6,9 +6,14 @@ import (
 	controller "github.com/drshashwat/coolstream/server/CoolStreamMovieServer/controllers"
 )
 
-func SetupUnprotectedRoutes(router *gin.Engine) {
+func SetupUnprotectedRoutes(router *gin.Engine, deps Dependencies) {
 	router.GET("/movies", controller.GetMovies())
-	router.POST("/register", controller.RegisterUser())
+	router.GET("/movies/search", controller.SearchMovies())
+	router.GET("/genres", controller.GetGenres())
+	router.POST("/register", controller.RegisterUser(deps.Mailer))
 	router.POST("/login", controller.LoginUser())
-	router.PATCH("/updatereview/:imdb_id", controller.AdminReviewUpdate())
+	router.POST("/refresh", controller.RefreshToken())
+	router.POST("/password/forgot", controller.ForgotPassword(deps.Mailer))
+	router.POST("/password/reset", controller.ResetPassword())
+	router.POST("/verify-email", controller.VerifyEmail())
 }
@@ -0,0 +1,130 @@
+// Package textutil provides the text normalization and fuzzy matching helpers
+// behind catalog search
+package textutil
+
+import (
+	"html"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Normalize lowercases s, replaces everything but letters and digits with
+// spaces and collapses runs of spaces.
+func Normalize(s string) string {
+	return strings.Join(Words(s), " ")
+}
+
+// Words splits s into lowercase runs of letters and digits.
+func Words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Trigrams returns the sorted, de-duplicated character trigrams of every word
+// in s. Words are padded so that short words and word starts still produce
+// grams, which lets a misspelt query share most grams with the right title.
+func Trigrams(s string) []string {
+	seen := map[string]bool{}
+	for _, word := range Words(s) {
+		runes := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			seen[string(runes[i:i+3])] = true
+		}
+	}
+	grams := make([]string, 0, len(seen))
+	for gram := range seen {
+		grams = append(grams, gram)
+	}
+	slices.Sort(grams)
+	return grams
+}
+
+// TrigramSimilarity is the Jaccard index of the trigram sets of a and b.
+func TrigramSimilarity(a, b string) float64 {
+	gramsA, gramsB := Trigrams(a), Trigrams(b)
+	if len(gramsA) == 0 || len(gramsB) == 0 {
+		return 0
+	}
+	shared := 0
+	for _, gram := range gramsA {
+		if _, found := slices.BinarySearch(gramsB, gram); found {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(gramsA)+len(gramsB)-shared)
+}
+
+// Levenshtein returns the edit distance between a and b in runes.
+func Levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// MaxEdits is the number of typos tolerated in a word of the given length.
+func MaxEdits(word string) int {
+	switch n := len([]rune(word)); {
+	case n <= 3:
+		return 0
+	case n <= 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// FuzzyWordMatch reports whether word equals term, starts with it, or is
+// within MaxEdits(term) edits of it. Both are expected to be normalized.
+func FuzzyWordMatch(word, term string) bool {
+	if word == term || (len(term) >= 3 && strings.HasPrefix(word, term)) {
+		return true
+	}
+	return Levenshtein(word, term) <= MaxEdits(term)
+}
+
+// Highlight HTML-escapes text and wraps every word for which match returns
+// true in <em></em>. match receives the normalized word.
+func Highlight(text string, match func(word string) bool) string {
+	var b strings.Builder
+	runes := []rune(text)
+	isWordRune := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+
+	for i := 0; i < len(runes); {
+		j := i
+		if isWordRune(runes[i]) {
+			for j < len(runes) && isWordRune(runes[j]) {
+				j++
+			}
+			word := string(runes[i:j])
+			if match(strings.ToLower(word)) {
+				b.WriteString("<em>" + html.EscapeString(word) + "</em>")
+			} else {
+				b.WriteString(html.EscapeString(word))
+			}
+		} else {
+			for j < len(runes) && !isWordRune(runes[j]) {
+				j++
+			}
+			b.WriteString(html.EscapeString(string(runes[i:j])))
+		}
+		i = j
+	}
+	return b.String()
+}
@@ -0,0 +1,146 @@
+package textutil
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTrigrams(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"empty", "", []string{}},
+		{"only punctuation", "?!", []string{}},
+		{"short word", "ab", []string{"  a", " ab", "ab "}},
+		{"case and punctuation", "Up!", []string{"  u", " up", "up "}},
+		{"repeated word", "a a", []string{"  a", " a "}},
+		{"unicode", "Été", []string{"  é", " ét", "té ", "été"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Trigrams(tt.input); !slices.Equal(got, tt.want) {
+				t.Errorf("Trigrams(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrigramSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want float64
+	}{
+		{"identical", "The Matrix", "the matrix", 1},
+		{"empty", "", "The Matrix", 0},
+		{"disjoint", "abc", "xyz", 0},
+		{"half shared", "ab", "ac", 0.2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TrigramSimilarity(tt.a, tt.b); got != tt.want {
+				t.Errorf("TrigramSimilarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"abc", "abc", 0},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"café", "cafe", 1},
+		{"naïve", "naive", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			if got := Levenshtein(tt.a, tt.b); got != tt.want {
+				t.Errorf("Levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaxEdits(t *testing.T) {
+	tests := []struct {
+		word string
+		want int
+	}{
+		{"", 0},
+		{"abc", 0},
+		{"abcd", 1},
+		{"abcdef", 1},
+		{"abcdefg", 2},
+		{"éééé", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if got := MaxEdits(tt.word); got != tt.want {
+				t.Errorf("MaxEdits(%q) = %d, want %d", tt.word, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFuzzyWordMatch(t *testing.T) {
+	tests := []struct {
+		name string
+		word string
+		term string
+		want bool
+	}{
+		{"equal", "matrix", "matrix", true},
+		{"both empty", "", "", true},
+		{"empty term", "matrix", "", false},
+		{"prefix", "matrix", "mat", true},
+		{"prefix too short", "matrix", "ma", false},
+		{"typo in short term", "cat", "car", false},
+		{"one typo", "alien", "alian", true},
+		{"two typos in six letters", "abcdef", "abcdxy", false},
+		{"two typos in long term", "godfather", "gadfathr", true},
+		{"three typos in long term", "godfather", "gadfothr", false},
+		{"unicode typo", "amélie", "amelie", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FuzzyWordMatch(tt.word, tt.term); got != tt.want {
+				t.Errorf("FuzzyWordMatch(%q, %q) = %v, want %v", tt.word, tt.term, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	matchWord := func(want string) func(string) bool {
+		return func(word string) bool { return word == want }
+	}
+	tests := []struct {
+		name  string
+		text  string
+		match string
+		want  string
+	}{
+		{"empty", "", "alien", ""},
+		{"no match", "It's alive", "alien", "It&#39;s alive"},
+		{"match is lower case", "Tom & Jerry", "tom", "<em>Tom</em> &amp; Jerry"},
+		{"markup is escaped", "<b>Alien</b>", "alien", "&lt;b&gt;<em>Alien</em>&lt;/b&gt;"},
+		{"unicode", "Amélie!", "amélie", "<em>Amélie</em>!"},
+		{"every occurrence", "Up up", "up", "<em>Up</em> <em>up</em>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Highlight(tt.text, matchWord(tt.match)); got != tt.want {
+				t.Errorf("Highlight(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
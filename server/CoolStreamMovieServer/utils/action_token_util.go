@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/database"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/models"
+)
+
+// ErrInvalidActionToken covers action tokens that are malformed, signed for
+// another purpose, expired or already used.
+var ErrInvalidActionToken = errors.New("invalid or expired token")
+
+var actionTokenCollection = database.LazyCollection("action_tokens")
+
+// ActionClaims are the claims of an emailed token, such as a password reset
+// link.
+type ActionClaims struct {
+	UID     string
+	Purpose string
+	jwt.RegisteredClaims
+}
+
+// GenerateActionToken issues a single-use token for purpose that expires
+// after ttl. Tokens issued earlier for the same user and purpose stop
+// working.
+func GenerateActionToken(userID, purpose string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := &ActionClaims{
+		UID:     userID,
+		Purpose: purpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        bson.NewObjectID().Hex(),
+			Issuer:    "CoolStream",
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+	signedToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(actionTokenKey(purpose))
+	if err != nil {
+		log.Error().Err(err).Msg("error in signing action token")
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	filter := bson.M{"user_id": userID, "purpose": purpose, "used_at": bson.M{"$exists": false}}
+	if _, err := actionTokenCollection().DeleteMany(ctx, filter); err != nil {
+		return "", err
+	}
+	record := models.ActionToken{
+		JTI:       claims.ID,
+		UserID:    userID,
+		Purpose:   purpose,
+		CreatedAt: now,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}
+	if _, err := actionTokenCollection().InsertOne(ctx, record); err != nil {
+		return "", err
+	}
+	return signedToken, nil
+}
+
+// RedeemActionToken checks a token issued for purpose and marks it used. It
+// returns ErrInvalidActionToken for any token that cannot be redeemed.
+func RedeemActionToken(tokenString, purpose string) (*ActionClaims, error) {
+	claims := &ActionClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (any, error) {
+		return actionTokenKey(purpose), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil || !token.Valid || claims.Purpose != purpose || claims.ID == "" {
+		return nil, ErrInvalidActionToken
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"jti":     claims.ID,
+		"user_id": claims.UID,
+		"purpose": purpose,
+		"used_at": bson.M{"$exists": false},
+	}
+	result, err := actionTokenCollection().UpdateOne(ctx, filter, bson.M{"$set": bson.M{"used_at": time.Now()}})
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrInvalidActionToken
+	}
+	return claims, nil
+}
+
+// actionTokenKey derives a signing key per purpose from SECRET_KEY, so that
+// an action token is never accepted as an access token or for another
+// purpose.
+func actionTokenKey(purpose string) []byte {
+	mac := hmac.New(sha256.New, []byte(SECRET_KEY))
+	mac.Write([]byte("action:" + purpose))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/cache"
+	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/database"
+)
+
+// revocationCacheTTL bounds how long another instance may keep accepting a
+// token after it was revoked elsewhere.
+const revocationCacheTTL = 30 * time.Second
+
+var (
+	revokedTokenCollection = database.LazyCollection("revoked_tokens")
+
+	tokenVersionCache = cache.NewTTL[string, int](revocationCacheTTL)
+	revokedTokenCache = cache.NewTTL[string, bool](revocationCacheTTL)
+)
+
+// RevokeToken puts a single token on the denylist until it expires.
+func RevokeToken(claims *SignedDetails) error {
+	if claims.ID == "" {
+		return errors.New("token has no id")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	filter := bson.M{"jti": claims.ID}
+	update := bson.M{
+		"$setOnInsert": bson.M{
+			"jti":        claims.ID,
+			"user_id":    claims.UID,
+			"expires_at": claims.ExpiresAt.Time,
+			"revoked_at": time.Now(),
+		},
+	}
+	_, err := revokedTokenCollection().UpdateOne(ctx, filter, update, options.UpdateOne().SetUpsert(true))
+	if err != nil {
+		log.Error().Err(err).Msg("error in revoking token")
+		return err
+	}
+	revokedTokenCache.Set(claims.ID, true)
+	return nil
+}
+
+// IsTokenRevoked reports whether the token was logged out or issued before
+// the user's last "log out all devices". Lookups are cached in process so
+// that most requests do not hit the database.
+func IsTokenRevoked(claims *SignedDetails) (bool, error) {
+	version, err := currentTokenVersion(claims.UID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return true, nil
+		}
+		return false, err
+	}
+	if claims.TokenVersion != version {
+		return true, nil
+	}
+	if claims.ID == "" {
+		return false, nil
+	}
+
+	if revoked, ok := revokedTokenCache.Get(claims.ID); ok {
+		return revoked, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	err = revokedTokenCollection().FindOne(ctx, bson.M{"jti": claims.ID}).Err()
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return false, err
+	}
+	revoked := err == nil
+	revokedTokenCache.Set(claims.ID, revoked)
+	return revoked, nil
+}
+
+func currentTokenVersion(userID string) (int, error) {
+	if version, ok := tokenVersionCache.Get(userID); ok {
+		return version, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	var result struct {
+		TokenVersion int `bson:"token_version"`
+	}
+	opts := options.FindOne().SetProjection(bson.M{"token_version": 1, "_id": 0})
+	err := userCollection().FindOne(ctx, bson.M{"user_id": userID}, opts).Decode(&result)
+	if err != nil {
+		return 0, err
+	}
+	tokenVersionCache.Set(userID, result.TokenVersion)
+	return result.TokenVersion, nil
+}
@@ -4,12 +4,12 @@ import (
 	"context"
 	"errors"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"go.mongodb.org/mongo-driver/v2/bson"
-	"go.mongodb.org/mongo-driver/v2/mongo"
 
 	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/database"
 	"github.com/drshashwat/coolstream/server/CoolStreamMovieServer/logger"
@@ -21,25 +21,40 @@ type SignedDetails struct {
 	Email     string
 	UID       string
 	Role      string
+	// TokenVersion must match the user's token_version for the token to be
+	// accepted; bumping it revokes every token issued before.
+	TokenVersion int
+	// EmailVerified is nil on tokens issued before email verification
+	// existed; every user of that time was migrated to verified.
+	EmailVerified *bool `json:",omitempty"`
 	jwt.RegisteredClaims
 }
 
+// IsEmailVerified reports whether the token's user had verified their email
+// address when the token was issued.
+func (c *SignedDetails) IsEmailVerified() bool {
+	return c.EmailVerified == nil || *c.EmailVerified
+}
+
 var (
 	SECRET_KEY         string = os.Getenv("SECRET_KEY")
 	SECRET_REFRESH_KEY        = os.Getenv("SECRET_REFRESH_KEY")
 
-	userCollection *mongo.Collection = database.OpenCollection("users")
-	log                              = logger.GetLogger()
+	userCollection = database.LazyCollection("users")
+	log            = logger.GetLogger()
 )
 
-func GenerateAllTokens(email, firstName, lastName, role, userID string) (string, string, error) {
+func GenerateAllTokens(email, firstName, lastName, role, userID string, tokenVersion int, emailVerified bool) (string, string, error) {
 	claims := &SignedDetails{
-		Email:     email,
-		FirstName: firstName,
-		LastName:  lastName,
-		Role:      role,
-		UID:       userID,
+		Email:         email,
+		FirstName:     firstName,
+		LastName:      lastName,
+		Role:          role,
+		UID:           userID,
+		TokenVersion:  tokenVersion,
+		EmailVerified: &emailVerified,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        bson.NewObjectID().Hex(),
 			Issuer:    "CoolStream",
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
@@ -53,12 +68,15 @@ func GenerateAllTokens(email, firstName, lastName, role, userID string) (string,
 	}
 
 	refreshClaims := &SignedDetails{
-		Email:     email,
-		FirstName: firstName,
-		LastName:  lastName,
-		Role:      role,
-		UID:       userID,
+		Email:         email,
+		FirstName:     firstName,
+		LastName:      lastName,
+		Role:          role,
+		UID:           userID,
+		TokenVersion:  tokenVersion,
+		EmailVerified: &emailVerified,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        bson.NewObjectID().Hex(),
 			Issuer:    "CoolStream",
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * 7 * time.Hour)),
@@ -88,30 +106,106 @@ func UpdateAllTokens(userID, token, refershToken string) (err error) {
 			"updated_at":    updateAt,
 		},
 	}
-	_, err = userCollection.UpdateOne(ctx, bson.M{"user_id": userID}, updateData)
+	_, err = userCollection().UpdateOne(ctx, bson.M{"user_id": userID}, updateData)
 	if err != nil {
 		log.Error().Err(err).Msg("error in updating token")
 	}
 	return
 }
 
+// RotateAllTokens replaces the stored tokens only if the stored refresh token
+// still equals oldRefreshToken. It reports false when the refresh token has
+// already been rotated or revoked, which means it is being replayed.
+func RotateAllTokens(userID, oldRefreshToken, token, refreshToken string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	filter := bson.M{"user_id": userID, "refresh_token": oldRefreshToken}
+	updateData := bson.M{
+		"$set": bson.M{
+			"token":         token,
+			"refresh_token": refreshToken,
+			"updated_at":    time.Now(),
+		},
+	}
+	result, err := userCollection().UpdateOne(ctx, filter, updateData)
+	if err != nil {
+		log.Error().Err(err).Msg("error in rotating tokens")
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+// ClearStoredTokens clears the stored tokens if token is still the latest
+// access token issued to the user, so its refresh token can no longer be
+// exchanged after logout.
+func ClearStoredTokens(userID, token string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	updateData := bson.M{
+		"$set": bson.M{
+			"token":         "",
+			"refresh_token": "",
+			"updated_at":    time.Now(),
+		},
+	}
+	_, err := userCollection().UpdateOne(ctx, bson.M{"user_id": userID, "token": token}, updateData)
+	if err != nil {
+		log.Error().Err(err).Msg("error in clearing tokens")
+	}
+	return err
+}
+
+// RevokeAllTokens clears the stored tokens and bumps the user's token
+// version, which invalidates every access and refresh token issued so far on
+// every device.
+func RevokeAllTokens(userID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	updateData := bson.M{
+		"$set": bson.M{
+			"token":         "",
+			"refresh_token": "",
+			"updated_at":    time.Now(),
+		},
+		"$inc": bson.M{"token_version": 1},
+	}
+	_, err := userCollection().UpdateOne(ctx, bson.M{"user_id": userID}, updateData)
+	if err != nil {
+		log.Error().Err(err).Msg("error in revoking tokens")
+		return err
+	}
+	tokenVersionCache.Delete(userID)
+	return nil
+}
+
 func GetAccessToken(c *gin.Context) (string, error) {
 	authHeader := c.Request.Header.Get("Authorization")
 	if authHeader == "" {
 		return "", errors.New("authorization Header is required")
 	}
-	tokenString := authHeader[len("Bearer "):]
-	if tokenString == "" {
+	tokenString, found := strings.CutPrefix(authHeader, "Bearer ")
+	if !found || tokenString == "" {
 		return "", errors.New("bearer tolen is required")
 	}
 	return tokenString, nil
 }
 
 func ValidateToken(tokenString string) (*SignedDetails, error) {
+	return validateToken(tokenString, SECRET_KEY)
+}
+
+func ValidateRefreshToken(tokenString string) (*SignedDetails, error) {
+	return validateToken(tokenString, SECRET_REFRESH_KEY)
+}
+
+func validateToken(tokenString, key string) (*SignedDetails, error) {
 	claims := &SignedDetails{}
 
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (any, error) {
-		return []byte(SECRET_KEY), nil
+		return []byte(key), nil
 	})
 	if err != nil {
 		return nil, err
@@ -126,6 +220,18 @@ func ValidateToken(tokenString string) (*SignedDetails, error) {
 	return claims, nil
 }
 
+func GetClaimsFromContext(c *gin.Context) (*SignedDetails, error) {
+	value, exist := c.Get("claims")
+	if !exist {
+		return nil, errors.New("claims do not exist")
+	}
+	claims, ok := value.(*SignedDetails)
+	if !ok {
+		return nil, errors.New("unable to retrive claims")
+	}
+	return claims, nil
+}
+
 func GetUserIDFromContext(c *gin.Context) (string, error) {
 	userID, exist := c.Get("userId")
 	if !exist {